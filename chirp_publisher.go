@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+)
+
+// publishDueChirpsInterval is how often scheduled chirps are checked for publication.
+const publishDueChirpsInterval = 10 * time.Second
+
+// publishDueChirpsBatchSize is the maximum number of chirps published in a single transaction.
+const publishDueChirpsBatchSize = 100
+
+// chirpPublisher publishes scheduled chirps once their publication time has passed.
+// Due chirps are claimed with FOR UPDATE SKIP LOCKED, so several server instances
+// can run a publisher without publishing the same chirp twice.
+type chirpPublisher struct {
+	db         *database.Queries
+	dbConn     *sql.DB
+	federation *federation
+}
+
+// newChirpPublisher creates a publisher backed by the given connection pool.
+func newChirpPublisher(db *database.Queries, dbConn *sql.DB, federation *federation) *chirpPublisher {
+	return &chirpPublisher{db: db, dbConn: dbConn, federation: federation}
+}
+
+// PublishDue publishes every chirp that is due, in batches, and returns how many were published.
+func (p *chirpPublisher) PublishDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := p.publishBatch(ctx)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < publishDueChirpsBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// publishBatch publishes a batch of due chirps, notifies the users they mention or quote and queues their
+// delivery to remote followers, all in one transaction: if any of it fails, the chirps stay scheduled and
+// the next run tries again. It returns how many chirps were published.
+func (p *chirpPublisher) publishBatch(ctx context.Context) (int, error) {
+	tx, err := p.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := p.db.WithTx(tx)
+
+	// publish_at is written from Go in UTC, so it is compared with the same clock rather than
+	// the database's, whose session time zone may differ.
+	published, err := qtx.PublishDueChirps(ctx, database.PublishDueChirpsParams{
+		Now:   time.Now().UTC(),
+		Limit: publishDueChirpsBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, chirp := range published {
+		err = notifyChirpPublished(ctx, qtx, chirp)
+		if err != nil {
+			return 0, fmt.Errorf("notifying for chirp %s: %w", chirp.ID, err)
+		}
+		err = p.federation.enqueueChirpCreated(ctx, qtx, chirp)
+		if err != nil {
+			return 0, fmt.Errorf("federating chirp %s: %w", chirp.ID, err)
+		}
+	}
+	return len(published), tx.Commit()
+}
+
+// Run publishes due chirps immediately and then periodically until ctx is done.
+func (p *chirpPublisher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.PublishDue(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't publish scheduled chirps", "error", err)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "Published scheduled chirps", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/entities"
+	"github.com/google/uuid"
+)
+
+// errRechirpUnavailable is returned by renderChirp for a rechirp whose original the viewer can't see,
+// for instance because they block its author. It wraps sql.ErrNoRows: such a rechirp is as good as missing.
+var errRechirpUnavailable = fmt.Errorf("rechirped chirp is unavailable: %w", sql.ErrNoRows)
+
+// ChirpEntities lists the hashtags and mentions of a chirp so clients can render links.
+type ChirpEntities struct {
+	// Hashtags are the hashtags found in the chirp body.
+	Hashtags []HashtagEntity `json:"hashtags"`
+	// Mentions are the mentions of existing users found in the chirp body.
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+// HashtagEntity is a hashtag found in a chirp body.
+type HashtagEntity struct {
+	// Tag is the normalized hashtag, without its sigil.
+	Tag string `json:"tag"`
+	// Indices are the start and end offsets of the hashtag in the body, sigil included.
+	// They are counted in Unicode code points and the end is exclusive.
+	Indices [2]int `json:"indices"`
+}
+
+// MentionEntity is a mention of a user found in a chirp body.
+type MentionEntity struct {
+	// UserID is the identifier of the mentioned user.
+	UserID uuid.UUID `json:"user_id"`
+	// Indices are the start and end offsets of the mention in the body, sigil included.
+	// They are counted in Unicode code points and the end is exclusive.
+	Indices [2]int `json:"indices"`
+}
+
+// Author is the summary of a user's public profile shown with their chirps.
+type Author struct {
+	// ID is the unique identifier of the user.
+	ID uuid.UUID `json:"id"`
+	// Handle is the user's unique handle, without its '@'.
+	Handle string `json:"handle"`
+	// DisplayName is the name the user chose to display, if any.
+	DisplayName string `json:"display_name"`
+	// Avatar holds the URLs of the user's avatar in each size, or null if they haven't uploaded one.
+	Avatar ProfileImage `json:"avatar"`
+}
+
+// authorFromDB converts a database user into the author of a chirp.
+func (cfg *apiConfig) authorFromDB(u database.User) Author {
+	return Author{
+		ID:          u.ID,
+		Handle:      u.Handle,
+		DisplayName: u.DisplayName,
+		Avatar:      cfg.profileImage(u.AvatarKey, avatarSizes),
+	}
+}
+
+// saveChirpEntities parses the hashtags and mentions of a chirp and stores them in the join tables.
+// Mentions that don't resolve to an existing user, or to a user blocking or blocked by the author, are ignored.
+// Mentions are resolved against the users' handles only, e.g. "@alice", so that a chirp can't probe
+// which email addresses have an account.
+func saveChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, entity := range entities.Parse(chirp.Body) {
+		switch entity.Kind {
+		case entities.KindHashtag:
+			hashtag, err := q.UpsertHashtag(ctx, entities.NormalizeTag(entity.Text))
+			if err != nil {
+				return err
+			}
+			err = q.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+				ChirpID:    chirp.ID,
+				HashtagID:  hashtag.ID,
+				StartIndex: int32(entity.Start),
+				EndIndex:   int32(entity.End),
+			})
+			if err != nil {
+				return err
+			}
+		case entities.KindMention:
+			user, err := q.GetUserByHandle(ctx, entity.Text)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			blocked, err := q.UsersBlocked(ctx, database.UsersBlockedParams{
+				UserID:  chirp.UserID,
+				OtherID: user.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if blocked {
+				continue
+			}
+			err = q.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+				ChirpID:    chirp.ID,
+				UserID:     user.ID,
+				StartIndex: int32(entity.Start),
+				EndIndex:   int32(entity.End),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderChirp converts a database chirp into its JSON representation. It fails with errRechirpUnavailable
+// for a rechirp that renderChirps would leave out.
+func (cfg *apiConfig) renderChirp(ctx context.Context, viewer uuid.NullUUID, dbChirp database.Chirp) (Chirp, error) {
+	chirps, err := cfg.renderChirps(ctx, viewer, []database.Chirp{dbChirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	if len(chirps) == 0 {
+		return Chirp{}, errRechirpUnavailable
+	}
+	return chirps[0], nil
+}
+
+// renderChirps converts database chirps into their JSON representation, loading the authors, entities,
+// media and share counts of all chirps in batch and embedding the chirps they rechirp or quote.
+// The viewer, if any, is the user the chirps are rendered for; it determines their "bookmarked" flag.
+// Rechirps whose original the viewer can't see are left out.
+func (cfg *apiConfig) renderChirps(
+	ctx context.Context,
+	viewer uuid.NullUUID,
+	dbChirps []database.Chirp,
+) ([]Chirp, error) {
+	chirps, err := cfg.renderChirpsWithoutEmbeds(ctx, viewer, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedIDs []uuid.UUID
+	for _, dbChirp := range dbChirps {
+		if dbChirp.RepostOf.Valid {
+			sharedIDs = append(sharedIDs, dbChirp.RepostOf.UUID)
+		}
+		if dbChirp.QuoteOf.Valid {
+			sharedIDs = append(sharedIDs, dbChirp.QuoteOf.UUID)
+		}
+	}
+	if len(sharedIDs) == 0 {
+		return chirps, nil
+	}
+
+	dbShared, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		Ids:      sharedIDs,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	shared, err := cfg.renderChirpsWithoutEmbeds(ctx, viewer, dbShared)
+	if err != nil {
+		return nil, err
+	}
+	sharedByID := make(map[uuid.UUID]*Chirp, len(shared))
+	for i := range shared {
+		sharedByID[shared[i].ID] = &shared[i]
+	}
+
+	// A rechirp has nothing to show without its original, so it is left out when the viewer can't see it.
+	rendered := chirps[:0]
+	for i, dbChirp := range dbChirps {
+		if dbChirp.RepostOf.Valid {
+			chirps[i].RechirpOf = sharedByID[dbChirp.RepostOf.UUID]
+			if chirps[i].RechirpOf == nil {
+				continue
+			}
+		}
+		if dbChirp.QuoteOf.Valid {
+			chirps[i].QuoteOf = sharedByID[dbChirp.QuoteOf.UUID]
+		}
+		rendered = append(rendered, chirps[i])
+	}
+	return rendered, nil
+}
+
+// renderChirpsWithoutEmbeds converts database chirps into their JSON representation,
+// without embedding the chirps they rechirp or quote.
+func (cfg *apiConfig) renderChirpsWithoutEmbeds(
+	ctx context.Context,
+	viewer uuid.NullUUID,
+	dbChirps []database.Chirp,
+) ([]Chirp, error) {
+	ids := make([]uuid.UUID, 0, len(dbChirps))
+	userIDs := make([]uuid.UUID, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		ids = append(ids, dbChirp.ID)
+		userIDs = append(userIDs, dbChirp.UserID)
+	}
+
+	hashtags, err := cfg.db.GetHashtagsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := cfg.db.GetMentionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := cfg.db.GetMediaAttachmentsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	shareCounts, err := cfg.db.GetShareCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := cfg.db.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByChirp := make(map[uuid.UUID]*ChirpEntities, len(dbChirps))
+	for _, id := range ids {
+		entitiesByChirp[id] = &ChirpEntities{Hashtags: []HashtagEntity{}, Mentions: []MentionEntity{}}
+	}
+	for _, h := range hashtags {
+		e := entitiesByChirp[h.ChirpID]
+		e.Hashtags = append(e.Hashtags, HashtagEntity{
+			Tag:     h.Name,
+			Indices: [2]int{int(h.StartIndex), int(h.EndIndex)},
+		})
+	}
+	for _, m := range mentions {
+		e := entitiesByChirp[m.ChirpID]
+		e.Mentions = append(e.Mentions, MentionEntity{
+			UserID:  m.UserID,
+			Indices: [2]int{int(m.StartIndex), int(m.EndIndex)},
+		})
+	}
+
+	mediaByChirp := make(map[uuid.UUID][]MediaAttachment, len(dbChirps))
+	for _, a := range attachments {
+		mediaByChirp[a.ChirpID.UUID] = append(mediaByChirp[a.ChirpID.UUID], cfg.mediaAttachmentFromDB(a))
+	}
+
+	bookmarked := make(map[uuid.UUID]bool)
+	if viewer.Valid {
+		bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	authorsByID := make(map[uuid.UUID]Author, len(authors))
+	for _, u := range authors {
+		authorsByID[u.ID] = cfg.authorFromDB(u)
+	}
+
+	countsByChirp := make(map[uuid.UUID]database.GetShareCountsRow, len(shareCounts))
+	for _, c := range shareCounts {
+		countsByChirp[c.ChirpID] = c
+	}
+
+	chirps := make([]Chirp, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		if mediaByChirp[dbChirp.ID] == nil {
+			mediaByChirp[dbChirp.ID] = []MediaAttachment{}
+		}
+		chirps = append(chirps, Chirp{
+			ID:           dbChirp.ID,
+			CreatedAt:    dbChirp.CreatedAt,
+			UpdatedAt:    dbChirp.UpdatedAt,
+			UserID:       dbChirp.UserID,
+			Author:       authorsByID[dbChirp.UserID],
+			Body:         dbChirp.Body,
+			Entities:     *entitiesByChirp[dbChirp.ID],
+			Media:        mediaByChirp[dbChirp.ID],
+			RechirpCount: countsByChirp[dbChirp.ID].RechirpCount,
+			QuoteCount:   countsByChirp[dbChirp.ID].QuoteCount,
+			Status:       dbChirp.Status,
+			PublishAt:    nullTimePtr(dbChirp.PublishAt),
+			Bookmarked:   bookmarked[dbChirp.ID],
+			Visibility:   dbChirp.Visibility,
+			Language:     dbChirp.Language,
+		})
+	}
+	return chirps, nil
+}
+
+// nullTimePtr returns a pointer to the time held by t, or nil if t is NULL.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// chirpEventsChannel is the Postgres channel chirp events are announced on.
+	chirpEventsChannel = "chirp_events"
+	// notificationEventsChannel is the Postgres channel new notifications are announced on.
+	notificationEventsChannel = "notification_events"
+	// chirpEventsCleanupInterval is how often expired chirp events are deleted. DeleteExpiredChirpEvents
+	// keeps the events of the last 24 hours for clients resuming a stream.
+	chirpEventsCleanupInterval = time.Hour
+	// hubSubscriberBuffer is the number of events a subscriber may lag behind before being dropped.
+	hubSubscriberBuffer = 64
+)
+
+// hubEvent is an event announced by Postgres: either a chirp event or a new notification.
+type hubEvent struct {
+	// ChirpEventID is the ID of the chirp event, or zero for a notification.
+	ChirpEventID int64
+	// NotificationID is the ID of the new notification, if any.
+	NotificationID uuid.NullUUID
+	// UserID is the recipient of the notification, if any.
+	UserID uuid.UUID
+}
+
+// hubSubscriber receives the events announced while it is subscribed.
+type hubSubscriber struct {
+	// events delivers events in order. It is closed when the subscriber is dropped.
+	events chan hubEvent
+}
+
+// eventHub listens to the chirp events and notifications announced by Postgres, whichever server
+// instance caused them, and fans them out to the realtime subscribers of this instance.
+type eventHub struct {
+	db       *database.Queries
+	listener *pq.Listener
+
+	mu          sync.Mutex
+	subscribers map[*hubSubscriber]struct{}
+	closed      bool
+}
+
+// newEventHub creates a hub listening for events on its own connection to dbURL.
+func newEventHub(dbURL string, db *database.Queries) (*eventHub, error) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("Event listener failed", "error", err)
+		}
+	})
+	for _, channel := range []string{chirpEventsChannel, notificationEventsChannel} {
+		err := listener.Listen(channel)
+		if err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+	return &eventHub{
+		db:          db,
+		listener:    listener,
+		subscribers: make(map[*hubSubscriber]struct{}),
+	}, nil
+}
+
+// Subscribe registers a new subscriber. Callers must Unsubscribe it when done.
+// Once the hub is closed, subscribers are dropped right away.
+func (h *eventHub) Subscribe() *hubSubscriber {
+	sub := &hubSubscriber{events: make(chan hubEvent, hubSubscriberBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel. It is safe to call more than once.
+func (h *eventHub) Unsubscribe(sub *hubSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// broadcast delivers an event to every subscriber. Subscribers too slow to keep up are dropped;
+// their clients can reconnect and resume from the last event they received.
+func (h *eventHub) broadcast(event hubEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Run forwards announced events to subscribers and periodically deletes expired chirp events,
+// until ctx is done. It then drops every subscriber and closes the listener.
+func (h *eventHub) Run(ctx context.Context) {
+	cleanup := time.NewTicker(chirpEventsCleanupInterval)
+	defer cleanup.Stop()
+	defer h.close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-h.listener.Notify:
+			// A nil notification means the connection was re-established; events announced
+			// in the meantime are lost, but stream clients can still catch up by resuming.
+			if n == nil {
+				continue
+			}
+			event, err := parseHubEvent(n)
+			if err != nil {
+				slog.ErrorContext(ctx, "Invalid event", "event", n.Extra, "channel", n.Channel, "error", err)
+				continue
+			}
+			h.broadcast(event)
+		case <-cleanup.C:
+			err := h.db.DeleteExpiredChirpEvents(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Couldn't delete expired chirp events", "error", err)
+			}
+		}
+	}
+}
+
+// close drops every subscriber, which ends their streams, and stops listening.
+func (h *eventHub) close() {
+	h.mu.Lock()
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+	h.mu.Unlock()
+	err := h.listener.Close()
+	if err != nil {
+		slog.Error("Couldn't close event listener", "error", err)
+	}
+}
+
+// parseHubEvent decodes the payload of a Postgres notification.
+func parseHubEvent(n *pq.Notification) (hubEvent, error) {
+	if n.Channel == chirpEventsChannel {
+		id, err := strconv.ParseInt(n.Extra, 10, 64)
+		return hubEvent{ChirpEventID: id}, err
+	}
+
+	userIDString, notificationIDString, _ := strings.Cut(n.Extra, ":")
+	userID, err := uuid.Parse(userIDString)
+	if err != nil {
+		return hubEvent{}, err
+	}
+	notificationID, err := uuid.Parse(notificationIDString)
+	if err != nil {
+		return hubEvent{}, err
+	}
+	return hubEvent{
+		NotificationID: uuid.NullUUID{UUID: notificationID, Valid: true},
+		UserID:         userID,
+	}, nil
+}
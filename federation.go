@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/activitypub"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// federationDeliveryInterval is how often due deliveries to remote servers are attempted.
+	federationDeliveryInterval = 10 * time.Second
+	// federationDeliveryBatchSize is the maximum number of deliveries claimed at once.
+	federationDeliveryBatchSize = 20
+	// federationDeliveryLease is how long a claimed delivery is hidden from other workers.
+	federationDeliveryLease = 5 * time.Minute
+	// federationMaxAttempts is the number of failed attempts after which a delivery is dropped.
+	federationMaxAttempts = 10
+	// federationMaxBackoff caps the delay between two attempts of a delivery.
+	federationMaxBackoff = 12 * time.Hour
+	// federationFetchFailureTTL is how long a remote actor that couldn't be fetched isn't fetched again.
+	federationFetchFailureTTL = 10 * time.Minute
+	// federationMaxFetchFailures caps the number of failed fetches remembered.
+	federationMaxFetchFailures = 10000
+)
+
+// federation publishes the public chirps of local users to the remote ActivityPub servers that
+// follow them. Activities are queued in the database within the transaction that causes them
+// and delivered by Run, which retries failed deliveries with exponential backoff. Delivery times
+// always come from the server's clock in UTC rather than the database's NOW(), whose value in a
+// TIMESTAMP column depends on the session's time zone.
+type federation struct {
+	db      *database.Queries
+	client  *activitypub.Client
+	baseURL string
+	host    string
+
+	// mu guards fetchFailures.
+	mu sync.Mutex
+	// fetchFailures maps the remote actors that couldn't be fetched recently to when the failure expires.
+	fetchFailures map[string]time.Time
+}
+
+// newFederation creates the federation of the server reachable at baseURL. Remote servers on private
+// networks are only reached when allowPrivate is set, for local development.
+func newFederation(db *database.Queries, baseURL string, allowPrivate bool) (*federation, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid public URL %q", baseURL)
+	}
+	return &federation{
+		db:      db,
+		client:  activitypub.NewClient("Chirpy (+"+baseURL+")", allowPrivate),
+		baseURL: baseURL,
+		host:    strings.ToLower(u.Host),
+
+		fetchFailures: make(map[string]time.Time),
+	}, nil
+}
+
+// fetchFailed reports whether fetching the remote actor at url failed recently.
+func (f *federation) fetchFailed(url string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	expiry, ok := f.fetchFailures[url]
+	if ok && time.Now().After(expiry) {
+		delete(f.fetchFailures, url)
+		return false
+	}
+	return ok
+}
+
+// recordFetchFailure remembers that fetching the remote actor at url failed. When too many failures
+// are remembered, the expired ones are forgotten, or all of them if none has expired.
+func (f *federation) recordFetchFailure(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if len(f.fetchFailures) >= federationMaxFetchFailures {
+		for u, expiry := range f.fetchFailures {
+			if now.After(expiry) {
+				delete(f.fetchFailures, u)
+			}
+		}
+		if len(f.fetchFailures) >= federationMaxFetchFailures {
+			clear(f.fetchFailures)
+		}
+	}
+	f.fetchFailures[url] = now.Add(federationFetchFailureTTL)
+}
+
+// actorID returns the ActivityPub ID of a local user.
+func (f *federation) actorID(userID uuid.UUID) string {
+	return f.baseURL + "/ap/users/" + userID.String()
+}
+
+// noteID returns the ActivityPub ID of a chirp.
+func (f *federation) noteID(chirpID uuid.UUID) string {
+	return f.baseURL + "/ap/chirps/" + chirpID.String()
+}
+
+// actor returns the actor document of a local user, creating their key pair on first use.
+// Actors are identified by user ID, so that they survive a change of handle, and named by handle.
+func (f *federation) actor(ctx context.Context, user database.User) (activitypub.Actor, error) {
+	key, err := f.actorKey(ctx, user.ID)
+	if err != nil {
+		return activitypub.Actor{}, err
+	}
+	actor := activitypub.NewPerson(f.actorID(user.ID), user.Handle, key.PublicKeyPem)
+	actor.Name = user.DisplayName
+	if user.Bio != "" {
+		actor.Summary = "<p>" + strings.ReplaceAll(html.EscapeString(user.Bio), "\n", "<br>") + "</p>"
+	}
+	actor.URL = f.baseURL + "/api/users/" + user.Handle
+	return actor, nil
+}
+
+// actorKey returns the key pair a local user signs activities with, creating it on first use.
+func (f *federation) actorKey(ctx context.Context, userID uuid.UUID) (database.ActorKey, error) {
+	key, err := f.db.GetActorKey(ctx, userID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return key, err
+	}
+	privateKeyPem, publicKeyPem, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+	// Concurrent requests may both generate a key; the first one stored wins.
+	err = f.db.CreateActorKey(ctx, database.CreateActorKeyParams{
+		UserID:        userID,
+		PrivateKeyPem: privateKeyPem,
+		PublicKeyPem:  publicKeyPem,
+	})
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+	return f.db.GetActorKey(ctx, userID)
+}
+
+// federated reports whether a chirp is published to remote servers: only public chirps are,
+// and rechirps are not.
+func federated(chirp database.Chirp) bool {
+	return chirp.Status == chirpStatusPublished && chirp.Visibility == chirpVisibilityPublic && !chirp.RepostOf.Valid
+}
+
+// note returns the ActivityPub object of a chirp.
+func (f *federation) note(chirp database.Chirp) activitypub.Note {
+	content := "<p>" + strings.ReplaceAll(html.EscapeString(chirp.Body), "\n", "<br>") + "</p>"
+	return activitypub.NewPublicNote(f.noteID(chirp.ID), f.actorID(chirp.UserID), content, chirp.CreatedAt)
+}
+
+// createActivity returns the Create activity publishing a chirp.
+func (f *federation) createActivity(chirp database.Chirp) activitypub.Activity {
+	note := f.note(chirp)
+	activity := activitypub.NewActivity(note.ID+"/activity", "Create", note.AttributedTo, note)
+	activity.To = note.To
+	activity.CC = note.CC
+	activity.Published = note.Published
+	return activity
+}
+
+// enqueueChirpCreated queues the delivery of a newly published chirp to the remote followers of its author,
+// if it is federated. q may be bound to the transaction publishing the chirp.
+func (f *federation) enqueueChirpCreated(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if !federated(chirp) {
+		return nil
+	}
+	return f.enqueueToFollowers(ctx, q, chirp.UserID, f.createActivity(chirp))
+}
+
+// enqueueChirpDeleted queues the deletion of a chirp on the remote servers it was delivered to.
+func (f *federation) enqueueChirpDeleted(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if !federated(chirp) {
+		return nil
+	}
+	actorID := f.actorID(chirp.UserID)
+	noteID := f.noteID(chirp.ID)
+	activity := activitypub.NewActivity(noteID+"#delete", "Delete", actorID, activitypub.NewTombstone(noteID))
+	activity.To = []string{activitypub.PublicAddress}
+	return f.enqueueToFollowers(ctx, q, chirp.UserID, activity)
+}
+
+// enqueueToFollowers queues an activity of a local user for every inbox of their remote followers.
+func (f *federation) enqueueToFollowers(
+	ctx context.Context,
+	q *database.Queries,
+	userID uuid.UUID,
+	activity activitypub.Activity,
+) error {
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	return q.EnqueueDeliveriesToFollowers(ctx, database.EnqueueDeliveriesToFollowersParams{
+		UserID:        userID,
+		Activity:      string(data),
+		NextAttemptAt: time.Now().UTC(),
+	})
+}
+
+// DeliverDue attempts every due delivery, in batches, and returns how many succeeded.
+func (f *federation) DeliverDue(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		now := time.Now().UTC()
+		deliveries, err := f.db.ClaimDueDeliveries(ctx, database.ClaimDueDeliveriesParams{
+			LeaseUntil: now.Add(federationDeliveryLease),
+			Now:        now,
+			Limit:      federationDeliveryBatchSize,
+		})
+		if err != nil {
+			return delivered, err
+		}
+		for _, delivery := range deliveries {
+			if f.deliver(ctx, delivery) {
+				delivered++
+			}
+		}
+		if len(deliveries) < federationDeliveryBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// deliver attempts a delivery and reports whether it succeeded. Deliveries that fail are retried
+// later, unless the remote server rejected them for good or they failed too many times.
+func (f *federation) deliver(ctx context.Context, delivery database.FederationDelivery) bool {
+	err := f.send(ctx, delivery)
+	if err == nil {
+		err = f.db.DeleteDelivery(ctx, delivery.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't delete delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		return true
+	}
+
+	var deliveryErr *activitypub.DeliveryError
+	if (errors.As(err, &deliveryErr) && deliveryErr.Permanent()) || delivery.Attempts+1 >= federationMaxAttempts {
+		slog.WarnContext(ctx, "Dropping delivery", "delivery_id", delivery.ID, "inbox", delivery.Inbox, "error", err)
+		err = f.db.DeleteDelivery(ctx, delivery.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't delete delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		return false
+	}
+	retryErr := f.db.RetryDelivery(ctx, database.RetryDeliveryParams{
+		ID:            delivery.ID,
+		NextAttemptAt: time.Now().UTC().Add(deliveryBackoff(delivery.Attempts)),
+		LastError:     sql.NullString{String: err.Error(), Valid: true},
+	})
+	if retryErr != nil {
+		slog.ErrorContext(ctx, "Couldn't reschedule delivery", "delivery_id", delivery.ID, "error", retryErr)
+	}
+	return false
+}
+
+// send signs a queued activity with the key of its local actor and posts it to the remote inbox.
+func (f *federation) send(ctx context.Context, delivery database.FederationDelivery) error {
+	key, err := f.actorKey(ctx, delivery.UserID)
+	if err != nil {
+		return err
+	}
+	privateKey, err := activitypub.ParsePrivateKey(key.PrivateKeyPem)
+	if err != nil {
+		return err
+	}
+	keyID := activitypub.KeyID(f.actorID(delivery.UserID))
+	return f.client.Deliver(ctx, delivery.Inbox, []byte(delivery.Activity), keyID, privateKey)
+}
+
+// deliveryBackoff returns the delay before the next attempt of a delivery that failed attempts times
+// before: one minute, doubling with every attempt.
+func deliveryBackoff(attempts int32) time.Duration {
+	backoff := time.Minute
+	for i := int32(0); i < attempts && backoff < federationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, federationMaxBackoff)
+}
+
+// Run attempts due deliveries immediately and then periodically until ctx is done.
+func (f *federation) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := f.DeliverDue(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't deliver activities", "error", err)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "Delivered activities", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
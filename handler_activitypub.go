@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/activitypub"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// outboxSize is the number of activities listed in an outbox.
+	outboxSize = 20
+	// maxInboxBodySize caps the size of the activities accepted by inboxes.
+	maxInboxBodySize = 1 << 20
+)
+
+// handlerWebFinger resolves an "acct:<handle>@<host>" resource, or the URL of an actor,
+// to the ActivityPub actor of a local user.
+func (cfg *apiConfig) handlerWebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	var name string
+	if strings.HasPrefix(resource, cfg.federation.baseURL+"/ap/users/") {
+		name = strings.TrimPrefix(resource, cfg.federation.baseURL+"/ap/users/")
+	} else {
+		user, host, err := activitypub.ParseAcct(resource)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid resource", err)
+			return
+		}
+		if host != cfg.federation.host {
+			respondWithError(w, r, http.StatusNotFound, "Unknown domain", nil)
+			return
+		}
+		name = user
+	}
+
+	user, ok := cfg.localActorUser(w, r, name)
+	if !ok {
+		return
+	}
+	actor, err := cfg.federation.actor(r.Context(), user)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't build actor", err)
+		return
+	}
+	subject := "acct:" + actor.PreferredUsername + "@" + cfg.federation.host
+	respondWithJSONAs(w, r, http.StatusOK, activitypub.JRDContentType, activitypub.NewWebFinger(subject, actor))
+}
+
+// handlerAPActor serves the ActivityPub actor document of a user.
+func (cfg *apiConfig) handlerAPActor(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.localActorUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+	actor, err := cfg.federation.actor(r.Context(), user)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't build actor", err)
+		return
+	}
+	respondWithJSONAs(w, r, http.StatusOK, activitypub.ContentType, actor)
+}
+
+// handlerAPOutbox serves the latest Create activities of a user's public chirps.
+func (cfg *apiConfig) handlerAPOutbox(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.localActorUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+	total, err := cfg.db.CountPublicChirpsByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count chirps", err)
+		return
+	}
+	dbChirps, err := cfg.db.GetPublicChirpsByUserID(r.Context(), database.GetPublicChirpsByUserIDParams{
+		UserID: user.ID,
+		Limit:  outboxSize,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	items := make([]any, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		items = append(items, cfg.federation.createActivity(dbChirp))
+	}
+	id := cfg.federation.actorID(user.ID) + "/outbox"
+	respondWithJSONAs(w, r, http.StatusOK, activitypub.ContentType, activitypub.NewOrderedCollection(id, total, items))
+}
+
+// handlerAPFollowers serves the number of remote followers of a user, without listing them.
+func (cfg *apiConfig) handlerAPFollowers(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.localActorUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+	total, err := cfg.db.CountRemoteFollowers(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count followers", err)
+		return
+	}
+	id := cfg.federation.actorID(user.ID) + "/followers"
+	respondWithJSONAs(w, r, http.StatusOK, activitypub.ContentType, activitypub.NewOrderedCollection(id, total, nil))
+}
+
+// handlerAPNote serves the ActivityPub object of a public chirp.
+func (cfg *apiConfig) handlerAPNote(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	// Without a viewer, only public chirps are found.
+	dbChirp, err := cfg.db.GetChirp(r.Context(), database.GetChirpParams{ID: chirpID})
+	if err == nil && !federated(dbChirp) {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+
+	note := cfg.federation.note(dbChirp)
+	note.Context = activitypub.ActivityStreamsContext
+	respondWithJSONAs(w, r, http.StatusOK, activitypub.ContentType, note)
+}
+
+// handlerAPInbox accepts the activities remote servers deliver to a user. Requests must carry an HTTP
+// signature from the key of the activity's actor. Follow and Undo of a Follow update the user's remote
+// followers, a Follow being answered with an Accept; other activities are acknowledged and ignored.
+func (cfg *apiConfig) handlerAPInbox(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.localActorUser(w, r, r.PathValue("userID"))
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, "Activity is too large", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Couldn't read activity", err)
+		return
+	}
+
+	var activity activitypub.Activity
+	err = json.Unmarshal(body, &activity)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Couldn't decode activity", err)
+		return
+	}
+	remoteActor, err := cfg.verifyRemoteActor(r.Context(), r, body, activity.Actor)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't verify signature", err)
+		return
+	}
+	if activity.Actor != remoteActor.Uri {
+		respondWithError(w, r, http.StatusUnauthorized, "Activity not signed by its actor", nil)
+		return
+	}
+
+	actorID := cfg.federation.actorID(user.ID)
+	switch activity.Type {
+	case "Follow":
+		if activity.ObjectID() != actorID {
+			respondWithError(w, r, http.StatusBadRequest, "Follow is not addressed to this actor", nil)
+			return
+		}
+		err = cfg.acceptRemoteFollow(r.Context(), user.ID, remoteActor, activity)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't accept follow", err)
+			return
+		}
+	case "Undo":
+		_, err = cfg.db.DeleteRemoteFollow(r.Context(), database.DeleteRemoteFollowParams{
+			UserID:           user.ID,
+			RemoteActorID:    remoteActor.ID,
+			FollowActivityID: activity.ObjectID(),
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't undo follow", err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// acceptRemoteFollow records a remote follower and queues the Accept of their Follow.
+func (cfg *apiConfig) acceptRemoteFollow(
+	ctx context.Context,
+	userID uuid.UUID,
+	remoteActor database.RemoteActor,
+	follow activitypub.Activity,
+) error {
+	actorID := cfg.federation.actorID(userID)
+	accept := activitypub.NewActivity(actorID+"#accepts/"+uuid.NewString(), "Accept", actorID, follow)
+	data, err := json.Marshal(accept)
+	if err != nil {
+		return err
+	}
+
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.CreateRemoteFollow(ctx, database.CreateRemoteFollowParams{
+		UserID:           userID,
+		RemoteActorID:    remoteActor.ID,
+		FollowActivityID: follow.ID,
+	})
+	if err != nil {
+		return err
+	}
+	err = qtx.EnqueueDelivery(ctx, database.EnqueueDeliveryParams{
+		UserID:        userID,
+		Inbox:         remoteActor.Inbox,
+		Activity:      string(data),
+		NextAttemptAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// verifyRemoteActor checks the HTTP signature of an inbox request and returns the remote actor who signed it.
+// The actor's key is taken from the cache of remote actors, and fetched again from their server when
+// unknown or when it doesn't match, as keys may be rotated. Since anyone may post to an inbox, the key
+// is only fetched from the server of actorURI, the actor the activity claims, and servers that failed
+// to serve it recently aren't asked again.
+func (cfg *apiConfig) verifyRemoteActor(
+	ctx context.Context,
+	r *http.Request,
+	body []byte,
+	actorURI string,
+) (database.RemoteActor, error) {
+	keyID, err := activitypub.SignatureKeyID(r)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+
+	remoteActor, err := cfg.db.GetRemoteActorByKeyID(ctx, keyID)
+	if err == nil && verifyWithKey(r, body, remoteActor.PublicKeyPem) == nil {
+		return remoteActor, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.RemoteActor{}, err
+	}
+
+	remoteActor, err = cfg.fetchRemoteActor(ctx, keyID, actorURI)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	err = verifyWithKey(r, body, remoteActor.PublicKeyPem)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	return remoteActor, nil
+}
+
+// fetchRemoteActor retrieves the actor owning keyID from its server and caches it. The key must be
+// hosted on the same server as actorURI. Outside of development, only HTTPS actors are fetched.
+// Failed fetches are remembered for a while and not attempted again.
+func (cfg *apiConfig) fetchRemoteActor(ctx context.Context, keyID, actorURI string) (database.RemoteActor, error) {
+	u, err := url.Parse(keyID)
+	if err != nil || (u.Scheme != "https" && !(cfg.platform == "dev" && u.Scheme == "http")) {
+		return database.RemoteActor{}, errors.New("invalid key ID")
+	}
+	actorURL, err := url.Parse(actorURI)
+	if err != nil || u.Host == "" || !strings.EqualFold(u.Host, actorURL.Host) {
+		return database.RemoteActor{}, errors.New("key isn't hosted on the actor's server")
+	}
+	u.Fragment = ""
+	if cfg.federation.fetchFailed(u.String()) {
+		return database.RemoteActor{}, errors.New("actor couldn't be fetched recently")
+	}
+	actor, err := cfg.federation.client.FetchActor(ctx, u.String())
+	if err == nil && (actor.PublicKey.ID != keyID || actor.PublicKey.Owner != actor.ID) {
+		err = errors.New("key doesn't belong to the actor")
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			cfg.federation.recordFetchFailure(u.String())
+		}
+		return database.RemoteActor{}, err
+	}
+
+	sharedInbox := sql.NullString{}
+	if actor.Endpoints != nil && actor.Endpoints.SharedInbox != "" {
+		sharedInbox = sql.NullString{String: actor.Endpoints.SharedInbox, Valid: true}
+	}
+	return cfg.db.UpsertRemoteActor(ctx, database.UpsertRemoteActorParams{
+		Uri:          actor.ID,
+		Inbox:        actor.Inbox,
+		SharedInbox:  sharedInbox,
+		PublicKeyID:  actor.PublicKey.ID,
+		PublicKeyPem: actor.PublicKey.PublicKeyPem,
+	})
+}
+
+// verifyWithKey checks the HTTP signature of a request against a PEM-encoded public key.
+func verifyWithKey(r *http.Request, body []byte, publicKeyPem string) error {
+	key, err := activitypub.ParsePublicKey(publicKeyPem)
+	if err != nil {
+		return err
+	}
+	return activitypub.VerifyRequest(r, body, key, time.Now())
+}
+
+// localActorUser looks up the local user named by an ActivityPub path or WebFinger resource,
+// by handle or ID, responding with an error when there is none.
+func (cfg *apiConfig) localActorUser(w http.ResponseWriter, r *http.Request, name string) (database.User, bool) {
+	user, err := cfg.getUserByHandleOrID(r.Context(), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "User not found", err)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return database.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/profanity"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// BannedTerm represents a term handled by the profanity filter.
+type BannedTerm struct {
+	// ID is the unique identifier of the term.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the term was added.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the timestamp when the term was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+	// Term is the banned word, stored case folded.
+	Term string `json:"term"`
+	// Mode is the action taken when the term is found: "mask", "reject" or "flag".
+	Mode profanity.Mode `json:"mode"`
+}
+
+// handlerBannedTermsList lists the banned terms in alphabetical order.
+func (cfg *apiConfig) handlerBannedTermsList(w http.ResponseWriter, r *http.Request) {
+	dbTerms, err := cfg.db.ListBannedTerms(r.Context())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve banned terms", err)
+		return
+	}
+
+	terms := []BannedTerm{}
+	for _, dbTerm := range dbTerms {
+		terms = append(terms, bannedTermFromDB(dbTerm))
+	}
+	respondWithJSON(w, r, http.StatusOK, terms)
+}
+
+// handlerBannedTermsCreate adds a banned term and refreshes the profanity filter.
+// The term must be a single word; it is stored case folded.
+func (cfg *apiConfig) handlerBannedTermsCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Term string         `json:"term"`
+		Mode profanity.Mode `json:"mode"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+
+	term := profanity.Normalize(params.Term)
+	if term == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid term, must be a single word", nil)
+		return
+	}
+	if !params.Mode.Valid() {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid mode, must be 'mask', 'reject' or 'flag'", nil)
+		return
+	}
+
+	dbTerm, err := cfg.db.CreateBannedTerm(r.Context(), database.CreateBannedTermParams{
+		Term: term,
+		Mode: string(params.Mode),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(w, r, http.StatusConflict, "Term is already banned", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create banned term", err)
+		return
+	}
+
+	cfg.refreshProfanityFilter(r)
+	respondWithJSON(w, r, http.StatusCreated, bannedTermFromDB(dbTerm))
+}
+
+// handlerBannedTermsUpdate changes the mode of a banned term and refreshes the profanity filter.
+func (cfg *apiConfig) handlerBannedTermsUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Mode profanity.Mode `json:"mode"`
+	}
+
+	termID, err := uuid.Parse(r.PathValue("termID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid term ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	if !params.Mode.Valid() {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid mode, must be 'mask', 'reject' or 'flag'", nil)
+		return
+	}
+
+	dbTerm, err := cfg.db.UpdateBannedTermMode(r.Context(), database.UpdateBannedTermModeParams{
+		ID:   termID,
+		Mode: string(params.Mode),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find banned term", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update banned term", err)
+		return
+	}
+
+	cfg.refreshProfanityFilter(r)
+	respondWithJSON(w, r, http.StatusOK, bannedTermFromDB(dbTerm))
+}
+
+// handlerBannedTermsDelete removes a banned term and refreshes the profanity filter.
+func (cfg *apiConfig) handlerBannedTermsDelete(w http.ResponseWriter, r *http.Request) {
+	termID, err := uuid.Parse(r.PathValue("termID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid term ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBannedTerm(r.Context(), termID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete banned term", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find banned term", nil)
+		return
+	}
+
+	cfg.refreshProfanityFilter(r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshProfanityFilter reloads the banned terms after a change. A failure is only logged:
+// the change is saved and the periodic refresh will pick it up.
+func (cfg *apiConfig) refreshProfanityFilter(r *http.Request) {
+	err := cfg.profanity.Refresh(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Couldn't refresh banned terms", "error", err)
+	}
+}
+
+// bannedTermFromDB converts a database banned term into its JSON representation.
+func bannedTermFromDB(dbTerm database.BannedTerm) BannedTerm {
+	return BannedTerm{
+		ID:        dbTerm.ID,
+		CreatedAt: dbTerm.CreatedAt,
+		UpdatedAt: dbTerm.UpdatedAt,
+		Term:      dbTerm.Term,
+		Mode:      profanity.Mode(dbTerm.Mode),
+	}
+}
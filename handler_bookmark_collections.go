@@ -0,0 +1,218 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/textnorm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxCollectionNameLength is the maximum number of characters of a bookmark collection name.
+const maxCollectionNameLength = 100
+
+// BookmarkCollection represents a named, private group of bookmarks.
+type BookmarkCollection struct {
+	// ID is the unique identifier of the collection.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the collection was created.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the timestamp when the collection was last renamed.
+	UpdatedAt time.Time `json:"updated_at"`
+	// Name is the name of the collection, unique per user.
+	Name string `json:"name"`
+}
+
+// handlerBookmarkCollectionsList lists the authenticated user's bookmark collections by name.
+func (cfg *apiConfig) handlerBookmarkCollectionsList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	dbCollections, err := cfg.db.GetBookmarkCollections(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve collections", err)
+		return
+	}
+
+	collections := []BookmarkCollection{}
+	for _, dbCollection := range dbCollections {
+		collections = append(collections, bookmarkCollectionFromDB(dbCollection))
+	}
+	respondWithJSON(w, r, http.StatusOK, collections)
+}
+
+// handlerBookmarkCollectionsCreate creates a bookmark collection for the authenticated user.
+func (cfg *apiConfig) handlerBookmarkCollectionsCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	name, err := validateCollectionName(params.Name)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbCollection, err := cfg.db.CreateBookmarkCollection(r.Context(), database.CreateBookmarkCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(w, r, http.StatusConflict, "A collection with this name already exists", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create collection", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusCreated, bookmarkCollectionFromDB(dbCollection))
+}
+
+// handlerBookmarkCollectionsUpdate renames one of the authenticated user's bookmark collections.
+func (cfg *apiConfig) handlerBookmarkCollectionsUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	collectionID, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid collection ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	name, err := validateCollectionName(params.Name)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbCollection, err := cfg.db.RenameBookmarkCollection(r.Context(), database.RenameBookmarkCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+		Name:   name,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(w, r, http.StatusConflict, "A collection with this name already exists", err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find collection", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't rename collection", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, bookmarkCollectionFromDB(dbCollection))
+}
+
+// handlerBookmarkCollectionsDelete deletes one of the authenticated user's bookmark collections.
+// The bookmarks it held are kept, outside of any collection.
+func (cfg *apiConfig) handlerBookmarkCollectionsDelete(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid collection ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmarkCollection(r.Context(), database.DeleteBookmarkCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete collection", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find collection", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateCollectionName normalizes a collection name and checks that it is neither blank nor too long.
+func validateCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(textnorm.Normalize(name))
+	if name == "" {
+		return "", errors.New("Collection name can't be empty")
+	}
+	if textnorm.Length(name) > maxCollectionNameLength {
+		return "", fmt.Errorf("Collection name can't be longer than %d characters", maxCollectionNameLength)
+	}
+	return name, nil
+}
+
+// bookmarkCollectionFromDB converts a database bookmark collection into its JSON representation.
+func bookmarkCollectionFromDB(c database.BookmarkCollection) BookmarkCollection {
+	return BookmarkCollection{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Name:      c.Name,
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerBookmarksPut bookmarks a chirp for the authenticated user, optionally in one of their collections.
+// Bookmarking an already bookmarked chirp moves it to the given collection, or out of any collection.
+func (cfg *apiConfig) handlerBookmarksPut(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		CollectionID *uuid.UUID `json:"collection_id"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	// The body is optional: an empty one bookmarks the chirp outside of any collection.
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+
+	_, err = cfg.db.GetChirp(r.Context(), database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: asViewer(userID),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+
+	var collectionID uuid.NullUUID
+	if params.CollectionID != nil {
+		collection, err := cfg.db.GetBookmarkCollection(r.Context(), database.GetBookmarkCollectionParams{
+			ID:     *params.CollectionID,
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusNotFound, "Couldn't find collection", err)
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: collection.ID, Valid: true}
+	}
+
+	err = cfg.db.UpsertBookmark(r.Context(), database.UpsertBookmarkParams{
+		UserID:       userID,
+		ChirpID:      chirpID,
+		CollectionID: collectionID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerBookmarksDelete removes a chirp from the authenticated user's bookmarks.
+func (cfg *apiConfig) handlerBookmarksDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete bookmark", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find bookmark", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerBookmarksList lists the authenticated user's bookmarked chirps, most recently bookmarked first,
+// with cursor pagination. The "collection_id" query parameter restricts the list to a collection.
+func (cfg *apiConfig) handlerBookmarksList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	var collectionID uuid.NullUUID
+	if s := r.URL.Query().Get("collection_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid collection ID", err)
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarks(r.Context(), database.GetBookmarksParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	dbChirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.renderChirps(r.Context(), asViewer(userID), dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
+	}
+
+	// Bookmarks are paginated by bookmark time rather than by chirp creation time.
+	resp := chirpPageResponse{Chirps: chirps}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.BookmarkedAt, last.Chirp.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
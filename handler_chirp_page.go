@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// chirpPageTemplate renders the permalink page of a chirp.
+var chirpPageTemplate = template.Must(template.New("chirp").Parse(`<!DOCTYPE html>
+<html>
+
+<head>
+	<meta charset="utf-8">
+	<title>{{.Author}} on Chirpy</title>
+	<link rel="alternate" type="application/json" href="{{.JSONLink}}">
+</head>
+
+<body>
+	<article>
+		<p>{{.Body}}</p>
+		<footer>{{.Author}} · <time datetime="{{.DateTime}}">{{.Date}}</time></footer>
+	</article>
+</body>
+
+</html>
+`))
+
+// handlerChirpPage serves the permalink page of a public chirp as HTML, which feed entries link to.
+func (cfg *apiConfig) handlerChirpPage(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	// Without a viewer, only public chirps are found.
+	dbChirp, err := cfg.db.GetChirp(r.Context(), database.GetChirpParams{ID: chirpID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+	author, err := cfg.db.GetUserByID(r.Context(), dbChirp.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve author", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = chirpPageTemplate.Execute(&buf, struct {
+		Author   string
+		Body     string
+		DateTime string
+		Date     string
+		JSONLink string
+	}{
+		Author:   "@" + author.Handle,
+		Body:     dbChirp.Body,
+		DateTime: dbChirp.CreatedAt.UTC().Format(time.RFC3339),
+		Date:     dbChirp.CreatedAt.UTC().Format("2 Jan 2006 15:04 UTC"),
+		JSONLink: cfg.publicURL + "/api/chirps/" + dbChirp.ID.String(),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirp", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
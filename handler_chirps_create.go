@@ -1,14 +1,22 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/alnah/go-httpserver/internal/auth"
 	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/profanity"
+	"github.com/alnah/go-httpserver/internal/spam"
+	"github.com/alnah/go-httpserver/internal/textnorm"
+	"github.com/alnah/go-httpserver/internal/trends"
 	"github.com/google/uuid"
 )
 
@@ -22,26 +30,77 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	// UserID is the identifier of the user who posted the chirp.
 	UserID uuid.UUID `json:"user_id"`
+	// Author is the public profile summary of the user who posted the chirp.
+	Author Author `json:"author"`
 	// Body is the content of the chirp.
 	Body string `json:"body"`
+	// Entities lists the hashtags and mentions found in the body.
+	Entities ChirpEntities `json:"entities"`
+	// Media lists the images attached to the chirp, in display order.
+	Media []MediaAttachment `json:"media"`
+	// RechirpOf is the original chirp when this chirp is a plain rechirp. Its body is then empty.
+	RechirpOf *Chirp `json:"rechirp_of,omitempty"`
+	// QuoteOf is the quoted chirp when this chirp is a quote.
+	QuoteOf *Chirp `json:"quote_of,omitempty"`
+	// RechirpCount is the number of times the chirp was rechirped.
+	RechirpCount int64 `json:"rechirp_count"`
+	// QuoteCount is the number of times the chirp was quoted.
+	QuoteCount int64 `json:"quote_count"`
+	// Status is "published", "scheduled" for a chirp waiting for its publication time,
+	// or "held" for a chirp the spam checks held for moderation.
+	Status string `json:"status"`
+	// PublishAt is the time a scheduled chirp will be published at.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// Visibility is who may see the chirp: "public", "followers" or "private" (the author only).
+	Visibility string `json:"visibility"`
+	// Language is the ISO 639-1 code of the chirp's language, or empty if unknown.
+	Language string `json:"language"`
+	// Bookmarked reports whether the caller has bookmarked the chirp. It is always false for anonymous callers.
+	Bookmarked bool `json:"bookmarked"`
 }
 
-// handlerChirpsCreate creates a new chirp.
-// It validates the user's JWT, decodes the chirp content, cleans it by filtering banned words,
-// and inserts the new chirp into the database.
+// Chirp statuses, as stored in the chirps table.
+const (
+	chirpStatusPublished = "published"
+	chirpStatusScheduled = "scheduled"
+	chirpStatusHeld      = "held"
+)
+
+// Chirp visibilities, as stored in the chirps table.
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityPrivate   = "private"
+)
+
+// handlerChirpsCreate creates a new chirp, or a quote of another chirp when "quote_of" is set.
+// Its "visibility" defaults to public and its optional "language" is an ISO 639-1 code.
+// A chirp with a future "publish_at" is stored as scheduled and published later by the chirpPublisher.
+// Chirps the spam checks find suspicious are held for moderation (202) or rejected (422), and users
+// over their hourly or daily posting quota get a 429.
+// It validates the user's JWT, decodes the chirp content, cleans it by filtering banned terms,
+// flags it for review if needed, and inserts the new chirp into the database along with
+// its hashtags, mentions and media. "alt_texts" optionally describes the images of "media_ids", in the same
+// order, replacing the alt text given at upload; an empty entry keeps it.
 func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body       string      `json:"body"`
+		MediaIDs   []uuid.UUID `json:"media_ids"`
+		AltTexts   []string    `json:"alt_texts"`
+		QuoteOf    *uuid.UUID  `json:"quote_of"`
+		PublishAt  *time.Time  `json:"publish_at"`
+		Visibility string      `json:"visibility"`
+		Language   string      `json:"language"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	userID, err := cfg.validateJWT(r.Context(), token)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
 		return
 	}
 
@@ -49,60 +108,265 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 	params := parameters{}
 	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithDecodeError(w, r, err)
 		return
 	}
 
-	cleaned, err := validateChirp(params.Body)
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find user", err)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	cleaned, flagged, err := validateChirp(params.Body, cfg.chirpLengthLimit(user.IsChirpyRed), cfg.profanity.Load())
+	if err != nil {
+		respondWithChirpValidationError(w, r, err)
+		return
+	}
+	if len(params.MediaIDs) > maxMediaPerChirp {
+		msg := fmt.Sprintf("A chirp can't have more than %d images", maxMediaPerChirp)
+		respondWithError(w, r, http.StatusBadRequest, msg, nil)
+		return
+	}
+	if len(params.AltTexts) > len(params.MediaIDs) {
+		respondWithError(w, r, http.StatusBadRequest, "There are more alt texts than images", nil)
+		return
+	}
+	for _, altText := range params.AltTexts {
+		if utf8.RuneCountInString(altText) > maxAltTextLength {
+			respondWithError(w, r, http.StatusBadRequest, "Alt text is too long", nil)
+			return
+		}
+	}
+
+	visibility := params.Visibility
+	switch visibility {
+	case "":
+		visibility = chirpVisibilityPublic
+	case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityPrivate:
+	default:
+		respondWithError(w, r, http.StatusBadRequest,
+			"Invalid visibility, must be 'public', 'followers' or 'private'", nil)
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
-	})
+	language, err := trends.NormalizeLanguage(params.Language)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	status := chirpStatusPublished
+	var publishAt sql.NullTime
+	if params.PublishAt != nil {
+		if !params.PublishAt.After(time.Now()) {
+			respondWithError(w, r, http.StatusBadRequest, "publish_at must be in the future", nil)
+			return
+		}
+		status = chirpStatusScheduled
+		publishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
+
+	var quoteOf uuid.NullUUID
+	if params.QuoteOf != nil {
+		if strings.TrimSpace(cleaned) == "" {
+			respondWithError(w, r, http.StatusBadRequest, "A quote must have a body", nil)
+			return
+		}
+		quoted, err := cfg.getShareTarget(r.Context(), *params.QuoteOf, userID)
+		if err != nil {
+			respondWithError(w, r, http.StatusNotFound, "Couldn't find quoted chirp", err)
+			return
+		}
+		if quoted.Visibility != chirpVisibilityPublic {
+			respondWithError(w, r, http.StatusBadRequest, "Only public chirps can be quoted", nil)
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	verdict, err := cfg.scoreChirp(r.Context(), user, cleaned)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't check chirp for spam", err)
+		return
+	}
+	switch verdict.Decision {
+	case spam.Reject:
+		slog.WarnContext(r.Context(), "Rejected chirp as spam",
+			"user_id", userID, "score", verdict.Score, "reasons", verdict.Reasons)
+		respondWithError(w, r, http.StatusUnprocessableEntity, "Chirp looks like spam", nil)
+		return
+	case spam.Hold:
+		slog.InfoContext(r.Context(), "Held chirp for moderation",
+			"user_id", userID, "score", verdict.Score, "reasons", verdict.Reasons)
+		status = chirpStatusHeld
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	postingQuota, err := reservePost(r.Context(), qtx, user)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't check posting quota", err)
+		return
+	}
+	setRateLimitHeaders(w, postingQuota)
+	if !postingQuota.Allowed {
+		respondWithError(w, r, http.StatusTooManyRequests, "Posting limit reached", nil)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	dbChirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:       cleaned,
+		UserID:     userID,
+		QuoteOf:    quoteOf,
+		Status:     status,
+		PublishAt:  publishAt,
+		Visibility: visibility,
+		Language:   language,
 	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+	err = saveChirpEntities(r.Context(), qtx, dbChirp)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save chirp entities", err)
+		return
+	}
+	if status == chirpStatusPublished {
+		err = notifyChirpPublished(r.Context(), qtx, dbChirp)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't notify users", err)
+			return
+		}
+		err = cfg.federation.enqueueChirpCreated(r.Context(), qtx, dbChirp)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't federate chirp", err)
+			return
+		}
+	}
+	if status == chirpStatusHeld {
+		err = qtx.CreateChirpFlag(r.Context(), database.CreateChirpFlagParams{
+			ChirpID: dbChirp.ID,
+			Reason:  "spam: " + strings.Join(verdict.Reasons, ", "),
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't flag chirp", err)
+			return
+		}
+	}
+	for _, term := range flagged {
+		err = qtx.CreateChirpFlag(r.Context(), database.CreateChirpFlagParams{
+			ChirpID: dbChirp.ID,
+			Reason:  "banned term: " + term,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't flag chirp", err)
+			return
+		}
+	}
+	for i, mediaID := range params.MediaIDs {
+		var altText sql.NullString
+		if i < len(params.AltTexts) && params.AltTexts[i] != "" {
+			altText = sql.NullString{String: params.AltTexts[i], Valid: true}
+		}
+		attached, err := qtx.AttachMediaToChirp(r.Context(), database.AttachMediaToChirpParams{
+			ChirpID:  uuid.NullUUID{UUID: dbChirp.ID, Valid: true},
+			Position: int32(i),
+			AltText:  altText,
+			ID:       mediaID,
+			UserID:   userID,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't attach media", err)
+			return
+		}
+		if attached != 1 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid media ID: "+mediaID.String(), nil)
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create chirp", err)
+		return
+	}
+
+	chirp, err := cfg.renderChirp(r.Context(), asViewer(userID), dbChirp)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirp", err)
+		return
+	}
+	if status == chirpStatusHeld {
+		respondWithJSON(w, r, http.StatusAccepted, chirp)
+		return
+	}
+	respondWithJSON(w, r, http.StatusCreated, chirp)
+}
+
+// chirpTooLongError is returned by validateChirp when a chirp exceeds its author's length limit.
+type chirpTooLongError struct {
+	// Length is the number of characters of the chirp.
+	Length int
+	// Limit is the maximum number of characters allowed.
+	Limit int
+}
+
+func (e *chirpTooLongError) Error() string {
+	return fmt.Sprintf("Chirp is too long: %d characters, limit is %d", e.Length, e.Limit)
+}
+
+// chirpLengthLimit returns the maximum number of characters of a chirp for a membership tier.
+func (cfg *apiConfig) chirpLengthLimit(isChirpyRed bool) int {
+	if isChirpyRed {
+		return cfg.chirpMaxLengthRed
+	}
+	return cfg.chirpMaxLength
 }
 
-// validateChirp checks that the chirp's body does not exceed the maximum allowed length
-// and filters out any banned words. It returns the cleaned chirp or an error.
-func validateChirp(body string) (string, error) {
-	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+// validateChirp normalizes the chirp's body to NFC without control characters, checks that it
+// does not exceed maxLength characters (grapheme clusters), and runs it through the profanity filter.
+// It returns the cleaned chirp and the banned terms it should be flagged for, or an error if the chirp
+// is too long (a *chirpTooLongError) or contains a rejected term.
+func validateChirp(body string, maxLength int, filter *profanity.Filter) (string, []string, error) {
+	body = textnorm.Normalize(body)
+	if length := textnorm.Length(body); length > maxLength {
+		return "", nil, &chirpTooLongError{Length: length, Limit: maxLength}
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	result := filter.Check(body)
+	if result.Rejected {
+		return "", nil, errors.New("Chirp contains banned terms")
 	}
-	cleaned := getCleanedBody(body, badWords)
-	return cleaned, nil
+	return result.Cleaned, result.Flagged, nil
 }
 
-// getCleanedBody replaces banned words in the chirp body with asterisks.
-func getCleanedBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
-		}
+// respondWithChirpValidationError sends the error returned by validateChirp.
+// Length errors report the limit and the actual length alongside the message.
+func respondWithChirpValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	type tooLongResponse struct {
+		Error  string `json:"error"`
+		Limit  int    `json:"limit"`
+		Length int    `json:"length"`
+	}
+
+	var tooLong *chirpTooLongError
+	if errors.As(err, &tooLong) {
+		respondWithJSON(w, r, http.StatusBadRequest, tooLongResponse{
+			Error:  tooLong.Error(),
+			Limit:  tooLong.Limit,
+			Length: tooLong.Length,
+		})
+		return
 	}
-	cleaned := strings.Join(words, " ")
-	return cleaned
+	respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
 }
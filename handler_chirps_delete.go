@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/alnah/go-httpserver/internal/auth"
@@ -9,35 +10,46 @@ import (
 )
 
 // handlerChirpsDelete deletes a chirp if the authenticated user owns it.
-// It validates the chirp ID, the JWT from the request, and then removes the chirp from the database.
+// It validates the chirp ID, the JWT from the request, and then removes the chirp from the database
+// along with its images from the blob store. Authors may delete their chirps whatever their status,
+// which lets them withdraw a chirp held for moderation.
 func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request) {
 	chirpIDString := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDString)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
 		return
 	}
 
 	accessToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find token", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find token", err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(accessToken, cfg.jwtSecret)
+	userID, err := cfg.validateJWT(r.Context(), accessToken)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	dbChirp, err := cfg.db.GetChirpIncludingUnpublished(r.Context(), database.GetChirpIncludingUnpublishedParams{
+		ID:       chirpID,
+		ViewerID: asViewer(userID),
+	})
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Coudln't found chirp", err)
+		respondWithError(w, r, http.StatusNotFound, "Coudln't found chirp", err)
 		return
 	}
 
 	if dbChirp.UserID != userID {
-		respondWithError(w, http.StatusForbidden, "Couldn't delete chirp not owned by the user", err)
+		respondWithError(w, r, http.StatusForbidden, "Couldn't delete chirp not owned by the user", err)
+		return
+	}
+
+	attachments, err := cfg.db.GetMediaAttachmentsForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get chirp media", err)
 		return
 	}
 
@@ -46,8 +58,15 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 		UserID: userID,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete chirp", err)
 		return
 	}
+	err = cfg.federation.enqueueChirpDeleted(r.Context(), cfg.db, dbChirp)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Couldn't federate deletion of chirp", "chirp_id", chirpID, "error", err)
+	}
+	for _, attachment := range attachments {
+		cfg.deleteBlobs(attachment.StorageKey, attachment.ThumbnailKey)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
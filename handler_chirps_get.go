@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"slices"
 
@@ -10,27 +11,40 @@ import (
 
 // handlerChirpsGet retrieves a single chirp based on its ID.
 // It parses the chirp ID from the URL, fetches the chirp from the database, and returns it as JSON.
+// Authors and admins also see chirps that aren't published, such as scheduled, held or hidden ones.
 func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, r *http.Request) {
 	chirpIDString := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDString)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	viewer, err := cfg.viewerFromRequest(r)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		UserID:    dbChirp.UserID,
-		Body:      dbChirp.Body,
+	dbChirp, err := cfg.db.GetChirpIncludingUnpublished(r.Context(), database.GetChirpIncludingUnpublishedParams{
+		ID:       chirpID,
+		ViewerID: viewer,
 	})
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+
+	chirp, err := cfg.renderChirp(r.Context(), viewer, dbChirp)
+	if errors.Is(err, errRechirpUnavailable) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't get chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirp", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, chirp)
 }
 
 // handlerChirpsRetrieve retrieves a list of chirps.
@@ -38,18 +52,26 @@ func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Request) {
 	var authorID uuid.UUID
 	var dbChirps []database.Chirp
-	var err error
+
+	viewer, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
 
 	authorIDString := r.URL.Query().Get("author_id")
 	if authorIDString != "" {
 		authorID, err = uuid.Parse(authorIDString)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			respondWithError(w, r, http.StatusBadRequest, "Invalid author ID", err)
 			return
 		}
-		dbChirps, err = cfg.db.GetChirpsByUserID(r.Context(), authorID)
+		dbChirps, err = cfg.db.GetChirpsByUserID(r.Context(), database.GetChirpsByUserIDParams{
+			UserID:   authorID,
+			ViewerID: viewer,
+		})
 	} else {
-		dbChirps, err = cfg.db.GetChirps(r.Context())
+		dbChirps, err = cfg.db.GetChirps(r.Context(), viewer)
 	}
 	sortParam := r.URL.Query().Get("sort")
 	switch sortParam {
@@ -58,25 +80,20 @@ func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Reque
 	case "desc":
 		slices.Reverse(dbChirps)
 	default:
-		respondWithError(w, http.StatusBadRequest, "Invalid sort param, must be 'asc' or 'desc'", err)
+		respondWithError(w, r, http.StatusBadRequest, "Invalid sort param, must be 'asc' or 'desc'", err)
 		return
 	}
 
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
 		return
 	}
 
-	chirps := []Chirp{}
-	for _, dbChirp := range dbChirps {
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			UserID:    dbChirp.UserID,
-			Body:      dbChirp.Body,
-		})
+	chirps, err := cfg.renderChirps(r.Context(), viewer, dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirps)
+	respondWithJSON(w, r, http.StatusOK, chirps)
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerChirpsRechirp shares a chirp as is on the authenticated user's behalf.
+// Rechirping a rechirp shares the original chirp. A chirp can only be rechirped once per user.
+func (cfg *apiConfig) handlerChirpsRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	original, err := cfg.getShareTarget(r.Context(), chirpID, userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	if original.Visibility != chirpVisibilityPublic {
+		respondWithError(w, r, http.StatusBadRequest, "Only public chirps can be rechirped", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	dbChirp, err := qtx.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:   userID,
+		RepostOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusConflict, "Chirp already rechirped", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+
+	err = notify(r.Context(), qtx, notification{
+		UserID:  original.UserID,
+		Type:    notificationRechirp,
+		ActorID: asViewer(userID),
+		ChirpID: dbChirp.RepostOf,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't notify user", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't rechirp", err)
+		return
+	}
+
+	chirp, err := cfg.renderChirp(r.Context(), asViewer(userID), dbChirp)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirp", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusCreated, chirp)
+}
+
+// handlerChirpsUndoRechirp removes the authenticated user's rechirp of a chirp. The chirp may be given
+// by its own ID or by the ID of a rechirp of it, such as the rechirp being undone.
+func (cfg *apiConfig) handlerChirpsUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find rechirp", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getShareTarget returns the chirp a rechirp or quote of chirpID by userID should point to:
+// the original chirp when chirpID is itself a plain rechirp, chirpID otherwise.
+// It returns an error if the user can't see the chirp.
+func (cfg *apiConfig) getShareTarget(ctx context.Context, chirpID, userID uuid.UUID) (database.Chirp, error) {
+	dbChirp, err := cfg.db.GetChirp(ctx, database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: asViewer(userID),
+	})
+	if err != nil || !dbChirp.RepostOf.Valid {
+		return dbChirp, err
+	}
+	return cfg.db.GetChirp(ctx, database.GetChirpParams{
+		ID:       dbChirp.RepostOf.UUID,
+		ViewerID: asViewer(userID),
+	})
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerChirpsScheduledList lists the authenticated user's scheduled chirps,
+// ordered by publication time. Scheduled chirps are only ever visible to their author.
+func (cfg *apiConfig) handlerChirpsScheduledList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetScheduledChirpsByUserID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve scheduled chirps", err)
+		return
+	}
+	chirps, err := cfg.renderChirps(r.Context(), asViewer(userID), dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, chirps)
+}
+
+// handlerChirpsHeldList lists the authenticated user's chirps held for moderation, newest first.
+// Held chirps are only visible to their author and admins until they are approved; the author can
+// withdraw one by deleting it.
+func (cfg *apiConfig) handlerChirpsHeldList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetHeldChirpsByUserID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve held chirps", err)
+		return
+	}
+	chirps, err := cfg.renderChirps(r.Context(), asViewer(userID), dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, chirps)
+}
+
+// handlerChirpsScheduledUpdate edits the body and publication time of a scheduled chirp.
+// The new body goes through the same validation as a new chirp, and its entities and flags are recorded again.
+func (cfg *apiConfig) handlerChirpsScheduledUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body      string    `json:"body"`
+		PublishAt time.Time `json:"publish_at"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	if !params.PublishAt.After(time.Now()) {
+		respondWithError(w, r, http.StatusBadRequest, "publish_at must be in the future", nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	cleaned, flagged, err := validateChirp(params.Body, cfg.chirpLengthLimit(user.IsChirpyRed), cfg.profanity.Load())
+	if err != nil {
+		respondWithChirpValidationError(w, r, err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	dbChirp, err := qtx.UpdateScheduledChirp(r.Context(), database.UpdateScheduledChirpParams{
+		ID:        chirpID,
+		UserID:    userID,
+		Body:      cleaned,
+		PublishAt: sql.NullTime{Time: params.PublishAt.UTC(), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+	err = qtx.DeleteChirpHashtags(r.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save chirp entities", err)
+		return
+	}
+	err = qtx.DeleteChirpMentions(r.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save chirp entities", err)
+		return
+	}
+	err = saveChirpEntities(r.Context(), qtx, dbChirp)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save chirp entities", err)
+		return
+	}
+	err = qtx.DeleteChirpFlags(r.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't flag chirp", err)
+		return
+	}
+	for _, term := range flagged {
+		err = qtx.CreateChirpFlag(r.Context(), database.CreateChirpFlagParams{
+			ChirpID: dbChirp.ID,
+			Reason:  "banned term: " + term,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't flag chirp", err)
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update chirp", err)
+		return
+	}
+
+	chirp, err := cfg.renderChirp(r.Context(), asViewer(userID), dbChirp)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirp", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, chirp)
+}
+
+// handlerChirpsScheduledDelete cancels a scheduled chirp and removes its images from the blob store.
+func (cfg *apiConfig) handlerChirpsScheduledDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	attachments, err := cfg.db.GetMediaAttachmentsForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get chirp media", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find scheduled chirp", nil)
+		return
+	}
+	for _, attachment := range attachments {
+		cfg.deleteBlobs(attachment.StorageKey, attachment.ThumbnailKey)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
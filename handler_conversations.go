@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// Conversation represents a private one-to-one conversation, as seen by one of its participants.
+type Conversation struct {
+	// ID is the unique identifier of the conversation.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the conversation was started.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the timestamp of the last message.
+	UpdatedAt time.Time `json:"updated_at"`
+	// UserID is the identifier of the other participant.
+	UserID uuid.UUID `json:"user_id"`
+	// UnreadCount is the number of messages from the other participant not read yet.
+	UnreadCount int64 `json:"unread_count"`
+	// LastReadAt is when the caller last read the conversation.
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+	// OtherLastReadAt is when the other participant last read the conversation.
+	OtherLastReadAt *time.Time `json:"other_last_read_at,omitempty"`
+}
+
+// conversationPageResponse is a page of the caller's conversations.
+type conversationPageResponse struct {
+	// Conversations is the current page, most recently active first.
+	Conversations []Conversation `json:"conversations"`
+	// NextCursor fetches the next page when passed as the "cursor" query parameter.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// handlerConversationsList lists the authenticated user's conversations, most recently active first,
+// with cursor pagination.
+func (cfg *apiConfig) handlerConversationsList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.GetConversationsForUser(r.Context(), database.GetConversationsForUserParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	resp := conversationPageResponse{Conversations: []Conversation{}}
+	for _, row := range rows {
+		resp.Conversations = append(resp.Conversations, Conversation{
+			ID:              row.ID,
+			CreatedAt:       row.CreatedAt,
+			UpdatedAt:       row.UpdatedAt,
+			UserID:          row.OtherUserID,
+			UnreadCount:     row.UnreadCount,
+			LastReadAt:      nullTimePtr(row.LastReadAt),
+			OtherLastReadAt: nullTimePtr(row.OtherLastReadAt),
+		})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.UpdatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// handlerConversationsRead marks the authenticated user's conversation with another user as read.
+// The other participant sees it as a read receipt on the messages they sent.
+func (cfg *apiConfig) handlerConversationsRead(w http.ResponseWriter, r *http.Request) {
+	otherID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	conversation, err := cfg.getConversation(r, userID, otherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return
+	}
+
+	err = cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't mark conversation as read", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getConversation returns the conversation between two users, or sql.ErrNoRows if they never exchanged messages.
+func (cfg *apiConfig) getConversation(r *http.Request, userID, otherID uuid.UUID) (database.Conversation, error) {
+	userA, userB := conversationPair(userID, otherID)
+	return cfg.db.GetConversationBetween(r.Context(), database.GetConversationBetweenParams{
+		UserAID: userA,
+		UserBID: userB,
+	})
+}
+
+// conversationPair orders two user IDs the way the conversations table stores them,
+// matching the byte order Postgres uses to compare UUIDs.
+func conversationPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		return b, a
+	}
+	return a, b
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/entities"
+	"github.com/alnah/go-httpserver/internal/feed"
+	"github.com/google/uuid"
+)
+
+const (
+	// feedSize is the number of chirps in a feed.
+	feedSize = 50
+	// feedTitleLength is the maximum number of characters of a feed entry title.
+	feedTitleLength = 80
+	// feedMaxAge is how long clients and proxies may cache a feed without revalidating it.
+	feedMaxAge = "max-age=300"
+	// feedMaxVersions caps the number of feeds whose last change is remembered.
+	feedMaxVersions = 10000
+)
+
+// feedVersions remembers when the content of each feed last changed. Feeds are dated by their content
+// rather than by their chirps, whose dates don't move when a chirp is deleted or hidden, or when an older
+// chirp enters the feed in its place.
+type feedVersions struct {
+	mu sync.Mutex
+	// versions maps the path of a feed to its ETag and the time it was first served with it.
+	versions map[string]feedVersion
+}
+
+// feedVersion is the content of a feed, identified by its ETag, and the time it was first served.
+type feedVersion struct {
+	etag     string
+	modified time.Time
+}
+
+// newFeedVersions creates an empty record of feed versions.
+func newFeedVersions() *feedVersions {
+	return &feedVersions{versions: make(map[string]feedVersion)}
+}
+
+// modified returns when the feed at path started having the content identified by etag. A new content is
+// dated now, truncated to the second as Last-Modified dates are, and at least as late as updated.
+func (v *feedVersions) modified(path, etag string, updated time.Time) time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	version, ok := v.versions[path]
+	if ok && version.etag == etag {
+		return version.modified
+	}
+	modified := time.Now().Truncate(time.Second)
+	if updated.After(modified) {
+		modified = updated
+	}
+	if len(v.versions) >= feedMaxVersions {
+		clear(v.versions)
+	}
+	v.versions[path] = feedVersion{etag: etag, modified: modified}
+	return modified
+}
+
+// handlerFeedsGlobal serves the latest public chirps as an Atom or RSS feed, depending on the extension
+// of the path.
+func (cfg *apiConfig) handlerFeedsGlobal(w http.ResponseWriter, r *http.Request) {
+	dbChirps, err := cfg.db.GetPublicChirps(r.Context(), feedSize)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	cfg.respondWithFeed(w, r, feed.Feed{
+		ID:          cfg.publicURL + "/api/chirps",
+		Title:       "Chirpy",
+		Description: "The latest public chirps",
+		Link:        cfg.publicURL + "/api/chirps",
+	}, time.Time{}, dbChirps)
+}
+
+// handlerFeedsUser serves the latest public chirps of a user, given their handle or ID, as an Atom or RSS feed.
+func (cfg *apiConfig) handlerFeedsUser(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.getUserByHandleOrID(r.Context(), r.PathValue("handleOrID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetPublicChirpsByUserID(r.Context(), database.GetPublicChirpsByUserIDParams{
+		UserID: user.ID,
+		Limit:  feedSize,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	name := "@" + user.Handle
+	if user.DisplayName != "" {
+		name = user.DisplayName + " (@" + user.Handle + ")"
+	}
+	cfg.respondWithFeed(w, r, feed.Feed{
+		ID:          "urn:uuid:" + user.ID.String(),
+		Title:       "Chirps by " + name,
+		Description: "The latest public chirps by " + name,
+		Link:        cfg.publicURL + "/api/users/" + user.Handle,
+	}, user.CreatedAt, dbChirps)
+}
+
+// handlerFeedsTag serves the latest public chirps using a hashtag as an Atom or RSS feed.
+func (cfg *apiConfig) handlerFeedsTag(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid tag", nil)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetPublicChirpsByHashtag(r.Context(), database.GetPublicChirpsByHashtagParams{
+		Name:  tag,
+		Limit: feedSize,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	link := cfg.publicURL + "/api/tags/" + url.PathEscape(tag) + "/chirps"
+	cfg.respondWithFeed(w, r, feed.Feed{
+		ID:          link,
+		Title:       "#" + tag + " on Chirpy",
+		Description: "The latest public chirps using #" + tag,
+		Link:        link,
+	}, time.Time{}, dbChirps)
+}
+
+// respondWithFeed completes f with the given chirps and sends it in the format named by the extension of the
+// request path. The feed was last updated when its most recently updated chirp was, or at created when
+// it has none. Responses carry an ETag, a hash of the feed, and a Last-Modified date, the time the feed
+// got that hash, so that feed readers polling with If-None-Match or If-Modified-Since get a 304 when
+// nothing changed.
+func (cfg *apiConfig) respondWithFeed(
+	w http.ResponseWriter,
+	r *http.Request,
+	f feed.Feed,
+	created time.Time,
+	dbChirps []database.Chirp,
+) {
+	userIDs := make([]uuid.UUID, 0, len(dbChirps))
+	for _, dbChirp := range dbChirps {
+		userIDs = append(userIDs, dbChirp.UserID)
+	}
+	authors, err := cfg.db.GetUsersByIDs(r.Context(), userIDs)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve authors", err)
+		return
+	}
+	handles := make(map[uuid.UUID]string, len(authors))
+	for _, author := range authors {
+		handles[author.ID] = "@" + author.Handle
+	}
+
+	f.SelfLink = cfg.publicURL + r.URL.Path
+	f.Updated = created
+	for _, dbChirp := range dbChirps {
+		if dbChirp.UpdatedAt.After(f.Updated) {
+			f.Updated = dbChirp.UpdatedAt
+		}
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:        "urn:uuid:" + dbChirp.ID.String(),
+			Title:     feed.Summarize(dbChirp.Body, feedTitleLength),
+			Link:      cfg.publicURL + "/chirps/" + dbChirp.ID.String(),
+			Author:    handles[dbChirp.UserID],
+			Content:   dbChirp.Body,
+			Published: dbChirp.CreatedAt,
+			Updated:   dbChirp.UpdatedAt,
+		})
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Unix(0, 0)
+	}
+
+	var data []byte
+	switch path.Ext(r.URL.Path) {
+	case ".atom":
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		data, err = f.Atom()
+	case ".rss":
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		data, err = f.RSS()
+	default:
+		respondWithError(w, r, http.StatusNotFound, "Unknown feed format", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't encode feed", err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, "+feedMaxAge)
+	// ServeContent answers conditional requests, giving If-None-Match precedence over If-Modified-Since.
+	http.ServeContent(w, r, "", cfg.feeds.modified(r.URL.Path, etag, f.Updated), bytes.NewReader(data))
+}
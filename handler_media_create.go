@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxMediaUploadBytes is the maximum size of an uploaded image.
+	maxMediaUploadBytes = 5 << 20
+	// maxMediaUploadBodyBytes caps the body of an upload request, leaving room for the multipart
+	// framing and the alt text around the image.
+	maxMediaUploadBodyBytes = maxMediaUploadBytes + 64<<10
+	// maxMediaPerChirp is the maximum number of images attached to a chirp.
+	maxMediaPerChirp = 4
+	// maxAltTextLength is the maximum number of characters of an image description.
+	maxAltTextLength = 1000
+)
+
+// MediaAttachment represents an image uploaded by a user and attached to a chirp.
+type MediaAttachment struct {
+	// ID is the unique identifier of the attachment, referenced in "media_ids" when creating a chirp.
+	ID uuid.UUID `json:"id"`
+	// URL is the address of the full-size image.
+	URL string `json:"url"`
+	// ThumbnailURL is the address of the downscaled image.
+	ThumbnailURL string `json:"thumbnail_url"`
+	// ContentType is the MIME type of the full-size image.
+	ContentType string `json:"content_type"`
+	// Width is the width of the full-size image in pixels.
+	Width int32 `json:"width"`
+	// Height is the height of the full-size image in pixels.
+	Height int32 `json:"height"`
+	// AltText describes the image for people who can't see it.
+	AltText string `json:"alt_text"`
+}
+
+// handlerMediaCreate uploads an image to be attached to a chirp later.
+// It expects a multipart form with a "file" part and an optional "alt_text" part,
+// sniffs and re-encodes the image to strip its metadata, generates a thumbnail,
+// and stores both in the blob store.
+func (cfg *apiConfig) handlerMediaCreate(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	data, altText, err := readMediaUpload(r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, "Image is too large", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	img, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error(), err)
+		return
+	}
+	if errors.Is(err, media.ErrTooManyPixels) || errors.Is(err, media.ErrTooManyFrames) {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't process image", err)
+		return
+	}
+
+	id := uuid.New()
+	storageKey := "media/" + id.String() + img.Extension
+	thumbnailKey := "media/" + id.String() + "_thumb" + img.ThumbnailExtension
+	err = cfg.blobs.Put(r.Context(), storageKey, bytes.NewReader(img.Data), img.ContentType)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't store image", err)
+		return
+	}
+	err = cfg.blobs.Put(r.Context(), thumbnailKey, bytes.NewReader(img.Thumbnail), img.ThumbnailContentType)
+	if err != nil {
+		cfg.deleteBlobs(storageKey)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't store thumbnail", err)
+		return
+	}
+
+	attachment, err := cfg.db.CreateMediaAttachment(r.Context(), database.CreateMediaAttachmentParams{
+		ID:           id,
+		UserID:       userID,
+		ContentType:  img.ContentType,
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+		SizeBytes:    int32(len(img.Data)),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+		AltText:      altText,
+	})
+	if err != nil {
+		cfg.deleteBlobs(storageKey, thumbnailKey)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusCreated, cfg.mediaAttachmentFromDB(attachment))
+}
+
+// readMediaUpload reads the "file" and "alt_text" parts of a multipart upload.
+// An oversized image fails with an *http.MaxBytesError.
+func readMediaUpload(r *http.Request) ([]byte, string, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", errors.New("Request must be multipart/form-data")
+	}
+
+	var data []byte
+	var altText string
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch part.FormName() {
+		case "file":
+			data, err = io.ReadAll(io.LimitReader(part, maxMediaUploadBytes+1))
+			if err != nil {
+				return nil, "", err
+			}
+			if len(data) > maxMediaUploadBytes {
+				return nil, "", &http.MaxBytesError{Limit: maxMediaUploadBytes}
+			}
+		case "alt_text":
+			raw, err := io.ReadAll(io.LimitReader(part, maxAltTextLength*utf8.UTFMax+1))
+			if err != nil {
+				return nil, "", err
+			}
+			altText = string(raw)
+			if !utf8.ValidString(altText) || utf8.RuneCountInString(altText) > maxAltTextLength {
+				return nil, "", errors.New("Alt text is too long")
+			}
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, "", errors.New("Missing file")
+	}
+	return data, altText, nil
+}
+
+// mediaAttachmentFromDB converts a database attachment into its JSON representation.
+func (cfg *apiConfig) mediaAttachmentFromDB(attachment database.MediaAttachment) MediaAttachment {
+	return MediaAttachment{
+		ID:           attachment.ID,
+		URL:          cfg.blobs.URL(attachment.StorageKey),
+		ThumbnailURL: cfg.blobs.URL(attachment.ThumbnailKey),
+		ContentType:  attachment.ContentType,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		AltText:      attachment.AltText,
+	}
+}
+
+// deleteBlobs removes objects from the blob store, logging failures.
+// It is used to clean up after failed uploads and deleted chirps.
+func (cfg *apiConfig) deleteBlobs(keys ...string) {
+	for _, key := range keys {
+		err := cfg.blobs.Delete(context.Background(), key)
+		if err != nil {
+			slog.Error("Couldn't delete blob", "key", key, "error", err)
+		}
+	}
+}
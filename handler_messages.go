@@ -0,0 +1,273 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// Message represents a direct message sent in a conversation.
+type Message struct {
+	// ID is the unique identifier of the message.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the message was sent.
+	CreatedAt time.Time `json:"created_at"`
+	// ConversationID is the identifier of the conversation the message belongs to.
+	ConversationID uuid.UUID `json:"conversation_id"`
+	// SenderID is the identifier of the user who sent the message.
+	SenderID uuid.UUID `json:"sender_id"`
+	// Body is the content of the message.
+	Body string `json:"body"`
+	// Read reports whether the recipient has read the message.
+	Read bool `json:"read"`
+}
+
+// messagePageResponse is a page of the messages of a conversation.
+type messagePageResponse struct {
+	// Messages is the current page, most recent first.
+	Messages []Message `json:"messages"`
+	// NextCursor fetches the next page when passed as the "cursor" query parameter.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// handlerMessagesCreate sends a direct message from the authenticated user to another user,
+// starting their conversation if needed. Messages go through the same length and profanity checks
+// as chirps, flagged terms being recorded, and can't be sent between users who block each other.
+func (cfg *apiConfig) handlerMessagesCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	recipientID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	if recipientID == userID {
+		respondWithError(w, r, http.StatusBadRequest, "Users can't message themselves", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	_, err = cfg.db.GetUserByID(r.Context(), recipientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	blocked, err := cfg.db.UsersBlocked(r.Context(), database.UsersBlockedParams{
+		UserID:  userID,
+		OtherID: recipientID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, r, http.StatusForbidden, "Couldn't message a blocked user", nil)
+		return
+	}
+
+	cleaned, flagged, err := validateChirp(params.Body, cfg.chirpLengthLimit(user.IsChirpyRed), cfg.profanity.Load())
+	if err != nil {
+		respondWithChirpValidationError(w, r, err)
+		return
+	}
+	if strings.TrimSpace(cleaned) == "" {
+		respondWithError(w, r, http.StatusBadRequest, "A message must have a body", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	userA, userB := conversationPair(userID, recipientID)
+	conversation, err := qtx.UpsertConversation(r.Context(), database.UpsertConversationParams{
+		UserAID: userA,
+		UserBID: userB,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't start conversation", err)
+		return
+	}
+	for _, participantID := range []uuid.UUID{userA, userB} {
+		err = qtx.CreateConversationParticipant(r.Context(), database.CreateConversationParticipantParams{
+			ConversationID: conversation.ID,
+			UserID:         participantID,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't start conversation", err)
+			return
+		}
+	}
+
+	dbMessage, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Body:           cleaned,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	for _, term := range flagged {
+		err = qtx.CreateMessageFlag(r.Context(), database.CreateMessageFlagParams{
+			MessageID: dbMessage.ID,
+			Reason:    "banned term: " + term,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't flag message", err)
+			return
+		}
+	}
+	err = qtx.TouchConversation(r.Context(), conversation.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	// Sending a message implies having read the conversation up to it.
+	err = qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusCreated, messageFromDB(dbMessage, sql.NullTime{}))
+}
+
+// handlerMessagesList lists the messages of the authenticated user's conversation with another user,
+// most recent first, with cursor pagination. Messages sent by the caller report whether they were read.
+func (cfg *apiConfig) handlerMessagesList(w http.ResponseWriter, r *http.Request) {
+	otherID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	conversation, err := cfg.getConversation(r, userID, otherID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find conversation", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return
+	}
+
+	participants, err := cfg.db.GetConversationParticipants(r.Context(), conversation.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return
+	}
+	lastReadAt := make(map[uuid.UUID]sql.NullTime, len(participants))
+	for _, participant := range participants {
+		lastReadAt[participant.UserID] = participant.LastReadAt
+	}
+
+	dbMessages, err := cfg.db.GetMessages(r.Context(), database.GetMessagesParams{
+		ConversationID:  conversation.ID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+
+	resp := messagePageResponse{Messages: []Message{}}
+	for _, dbMessage := range dbMessages {
+		// A message is read once its recipient has read the conversation after it was sent.
+		recipientID := otherID
+		if dbMessage.SenderID == otherID {
+			recipientID = userID
+		}
+		resp.Messages = append(resp.Messages, messageFromDB(dbMessage, lastReadAt[recipientID]))
+	}
+	if len(dbMessages) > 0 {
+		last := dbMessages[len(dbMessages)-1]
+		resp.NextCursor = p.nextCursor(len(dbMessages), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// messageFromDB converts a database message into its JSON representation,
+// given when its recipient last read the conversation.
+func messageFromDB(m database.Message, recipientLastReadAt sql.NullTime) Message {
+	return Message{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+		Read:           recipientLastReadAt.Valid && !recipientLastReadAt.Time.Before(m.CreatedAt),
+	}
+}
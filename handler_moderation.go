@@ -0,0 +1,509 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxModerationNoteLength is the maximum number of characters of a moderator's note.
+	maxModerationNoteLength = 1000
+	// maxSuspensionDays is the longest suspension a moderator can hand out.
+	maxSuspensionDays = 3650
+)
+
+// Moderation actions, as stored in the moderation_actions table.
+const (
+	moderationDismiss      = "dismiss"
+	moderationHideChirp    = "hide_chirp"
+	moderationWarn         = "warn"
+	moderationSuspend      = "suspend"
+	moderationApproveChirp = "approve_chirp"
+	moderationRejectChirp  = "reject_chirp"
+)
+
+// ModerationAction is an entry of the moderation log.
+type ModerationAction struct {
+	// ID is the unique identifier of the action.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the action was taken.
+	CreatedAt time.Time `json:"created_at"`
+	// ModeratorID is the identifier of the moderator who took the action, if they still exist.
+	ModeratorID *uuid.UUID `json:"moderator_id"`
+	// ReportID is the identifier of the report the action closed, if any.
+	ReportID *uuid.UUID `json:"report_id"`
+	// Action is "dismiss", "hide_chirp", "warn" or "suspend" for reports,
+	// and "approve_chirp" or "reject_chirp" for held chirps.
+	Action string `json:"action"`
+	// UserID is the identifier of the user the action is about.
+	UserID uuid.UUID `json:"user_id"`
+	// ChirpID is the identifier of the chirp the action is about, if any.
+	ChirpID *uuid.UUID `json:"chirp_id,omitempty"`
+	// Note is the moderator's explanation.
+	Note string `json:"note"`
+	// SuspendedUntil is the end of the suspension handed out by a "suspend" action.
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+// moderationActionFromDB converts a database moderation action into its JSON representation.
+func moderationActionFromDB(a database.ModerationAction) ModerationAction {
+	action := ModerationAction{
+		ID:             a.ID,
+		CreatedAt:      a.CreatedAt,
+		Action:         a.Action,
+		UserID:         a.UserID,
+		Note:           a.Note,
+		SuspendedUntil: nullTimePtr(a.SuspendedUntil),
+	}
+	if a.ModeratorID.Valid {
+		action.ModeratorID = &a.ModeratorID.UUID
+	}
+	if a.ReportID.Valid {
+		action.ReportID = &a.ReportID.UUID
+	}
+	if a.ChirpID.Valid {
+		action.ChirpID = &a.ChirpID.UUID
+	}
+	return action
+}
+
+// isSuspended reports whether a user is serving a suspension. Suspended users can't log in,
+// refresh their tokens or use any route that changes state.
+func isSuspended(u database.User) bool {
+	return u.SuspendedUntil.Valid && u.SuspendedUntil.Time.After(time.Now().UTC())
+}
+
+// respondWithSuspended responds with 403 and the end of the user's suspension.
+func respondWithSuspended(w http.ResponseWriter, r *http.Request, u database.User) {
+	msg := "Account suspended until " + u.SuspendedUntil.Time.Format(time.RFC3339)
+	respondWithError(w, r, http.StatusForbidden, msg, nil)
+}
+
+// handlerReportsList lists the reports with the given "status" (open by default), oldest first,
+// so that moderators work through the queue in order.
+func (cfg *apiConfig) handlerReportsList(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []Report `json:"reports"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = reportStatusOpen
+	case reportStatusOpen, reportStatusResolved, reportStatusDismissed:
+	default:
+		respondWithError(w, r, http.StatusBadRequest, "Invalid status, must be 'open', 'resolved' or 'dismissed'", nil)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbReports, err := cfg.db.ListReports(r.Context(), database.ListReportsParams{
+		Status:          status,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve reports", err)
+		return
+	}
+
+	resp := response{Reports: []Report{}}
+	for _, report := range dbReports {
+		resp.Reports = append(resp.Reports, reportFromDB(report))
+	}
+	if len(dbReports) > 0 {
+		last := dbReports[len(dbReports)-1]
+		resp.NextCursor = p.nextCursor(len(dbReports), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// handlerReportsAct closes an open report with a moderation action:
+//   - "dismiss" closes the report without further action;
+//   - "hide_chirp" hides the reported chirp from everyone and retracts it from remote servers;
+//   - "warn" notifies the reported user;
+//   - "suspend" blocks the reported user from logging in and posting for "duration_days" days,
+//     and revokes their refresh tokens.
+//
+// The action and the moderator's "note" are recorded in the moderation log.
+func (cfg *apiConfig) handlerReportsAct(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action       string `json:"action"`
+		Note         string `json:"note"`
+		DurationDays int    `json:"duration_days"`
+	}
+	type response struct {
+		Report Report           `json:"report"`
+		Action ModerationAction `json:"action"`
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid report ID", err)
+		return
+	}
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	moderatorID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	switch params.Action {
+	case moderationDismiss, moderationHideChirp, moderationWarn:
+	case moderationSuspend:
+		if params.DurationDays < 1 || params.DurationDays > maxSuspensionDays {
+			msg := fmt.Sprintf("duration_days must be between 1 and %d", maxSuspensionDays)
+			respondWithError(w, r, http.StatusBadRequest, msg, nil)
+			return
+		}
+	default:
+		respondWithError(w, r, http.StatusBadRequest,
+			"Invalid action, must be 'dismiss', 'hide_chirp', 'warn' or 'suspend'", nil)
+		return
+	}
+	if utf8.RuneCountInString(params.Note) > maxModerationNoteLength {
+		respondWithError(w, r, http.StatusBadRequest, "Note is too long", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't act on report", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	report, err := qtx.GetReportForUpdate(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find report", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve report", err)
+		return
+	}
+	if report.Status != reportStatusOpen {
+		respondWithError(w, r, http.StatusConflict, "Report is already closed", nil)
+		return
+	}
+
+	status := reportStatusResolved
+	var suspendedUntil sql.NullTime
+	switch params.Action {
+	case moderationDismiss:
+		status = reportStatusDismissed
+	case moderationHideChirp:
+		if !report.ChirpID.Valid {
+			respondWithError(w, r, http.StatusBadRequest, "Report isn't about an existing chirp", nil)
+			return
+		}
+		hidden, err := qtx.HideChirp(r.Context(), report.ChirpID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusConflict, "Chirp isn't published", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't hide chirp", err)
+			return
+		}
+		// Remote servers received the chirp while it was published, so retract it as such.
+		retracted := hidden
+		retracted.Status = chirpStatusPublished
+		err = cfg.federation.enqueueChirpDeleted(r.Context(), qtx, retracted)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't federate hidden chirp", err)
+			return
+		}
+	case moderationSuspend:
+		until := time.Now().UTC().AddDate(0, 0, params.DurationDays)
+		suspendedUntil = sql.NullTime{Time: until, Valid: true}
+		err = qtx.SuspendUser(r.Context(), database.SuspendUserParams{
+			ID:             report.UserID,
+			SuspendedUntil: suspendedUntil,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't suspend user", err)
+			return
+		}
+		err = qtx.RevokeUserRefreshTokens(r.Context(), report.UserID)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't revoke refresh tokens", err)
+			return
+		}
+	}
+
+	action, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID:    uuid.NullUUID{UUID: moderatorID, Valid: true},
+		ReportID:       uuid.NullUUID{UUID: report.ID, Valid: true},
+		Action:         params.Action,
+		UserID:         report.UserID,
+		ChirpID:        report.ChirpID,
+		Note:           params.Note,
+		SuspendedUntil: suspendedUntil,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	report, err = qtx.CloseReport(r.Context(), database.CloseReportParams{ID: report.ID, Status: status})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't close report", err)
+		return
+	}
+	if params.Action != moderationDismiss {
+		err = notify(r.Context(), qtx, notification{
+			UserID:  report.UserID,
+			Type:    notificationModeration,
+			ChirpID: report.ChirpID,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't notify user", err)
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't act on report", err)
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusCreated, response{
+		Report: reportFromDB(report),
+		Action: moderationActionFromDB(action),
+	})
+}
+
+// handlerModerationActionsList lists the moderation log, most recent first, optionally only the actions
+// about the user given by the "user_id" query parameter.
+func (cfg *apiConfig) handlerModerationActionsList(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Actions    []ModerationAction `json:"actions"`
+		NextCursor string             `json:"next_cursor,omitempty"`
+	}
+
+	var userID uuid.NullUUID
+	if s := r.URL.Query().Get("user_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+			return
+		}
+		userID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbActions, err := cfg.db.ListModerationActions(r.Context(), database.ListModerationActionsParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve moderation actions", err)
+		return
+	}
+
+	resp := response{Actions: []ModerationAction{}}
+	for _, action := range dbActions {
+		resp.Actions = append(resp.Actions, moderationActionFromDB(action))
+	}
+	if len(dbActions) > 0 {
+		last := dbActions[len(dbActions)-1]
+		resp.NextCursor = p.nextCursor(len(dbActions), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// HeldChirp is a chirp held by the spam checks, waiting for a moderator.
+type HeldChirp struct {
+	Chirp
+	// Reasons lists why the chirp was flagged.
+	Reasons []string `json:"reasons"`
+}
+
+// handlerHeldChirpsList lists the chirps held by the spam checks, oldest first.
+func (cfg *apiConfig) handlerHeldChirpsList(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []HeldChirp `json:"chirps"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	dbChirps, err := cfg.db.ListHeldChirps(r.Context(), database.ListHeldChirpsParams{
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve held chirps", err)
+		return
+	}
+	chirps, err := cfg.renderChirps(r.Context(), uuid.NullUUID{}, dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(dbChirps))
+	for _, c := range dbChirps {
+		ids = append(ids, c.ID)
+	}
+	flags, err := cfg.db.GetChirpFlagsForChirps(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirp flags", err)
+		return
+	}
+	reasons := make(map[uuid.UUID][]string, len(dbChirps))
+	for _, f := range flags {
+		reasons[f.ChirpID] = append(reasons[f.ChirpID], f.Reason)
+	}
+
+	resp := response{Chirps: []HeldChirp{}}
+	for _, chirp := range chirps {
+		resp.Chirps = append(resp.Chirps, HeldChirp{Chirp: chirp, Reasons: reasons[chirp.ID]})
+	}
+	if len(dbChirps) > 0 {
+		last := dbChirps[len(dbChirps)-1]
+		resp.NextCursor = p.nextCursor(len(dbChirps), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// handlerHeldChirpsApprove publishes a held chirp, or schedules it if its publication time is still ahead.
+// A published chirp notifies the users it mentions or quotes and is delivered to remote followers.
+func (cfg *apiConfig) handlerHeldChirpsApprove(w http.ResponseWriter, r *http.Request) {
+	cfg.reviewHeldChirp(w, r, moderationApproveChirp)
+}
+
+// handlerHeldChirpsReject hides a held chirp for good.
+func (cfg *apiConfig) handlerHeldChirpsReject(w http.ResponseWriter, r *http.Request) {
+	cfg.reviewHeldChirp(w, r, moderationRejectChirp)
+}
+
+// reviewHeldChirp approves or rejects a held chirp and records the decision in the moderation log.
+// The moderator may explain it with a "note".
+func (cfg *apiConfig) reviewHeldChirp(w http.ResponseWriter, r *http.Request, action string) {
+	type parameters struct {
+		Note string `json:"note"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	moderatorID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	params := parameters{}
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			respondWithDecodeError(w, r, err)
+			return
+		}
+	}
+	if utf8.RuneCountInString(params.Note) > maxModerationNoteLength {
+		respondWithError(w, r, http.StatusBadRequest, "Note is too long", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't review chirp", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	var dbChirp database.Chirp
+	if action == moderationApproveChirp {
+		dbChirp, err = qtx.ApproveHeldChirp(r.Context(), database.ApproveHeldChirpParams{
+			Now: time.Now().UTC(),
+			ID:  chirpID,
+		})
+	} else {
+		dbChirp, err = qtx.RejectHeldChirp(r.Context(), chirpID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find held chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't review chirp", err)
+		return
+	}
+	if dbChirp.Status == chirpStatusPublished {
+		err = notifyChirpPublished(r.Context(), qtx, dbChirp)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't notify users", err)
+			return
+		}
+		err = cfg.federation.enqueueChirpCreated(r.Context(), qtx, dbChirp)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't federate chirp", err)
+			return
+		}
+	}
+	dbAction, err := qtx.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      action,
+		UserID:      dbChirp.UserID,
+		ChirpID:     uuid.NullUUID{UUID: dbChirp.ID, Valid: true},
+		Note:        params.Note,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't record moderation action", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't review chirp", err)
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, moderationActionFromDB(dbAction))
+}
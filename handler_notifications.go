@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// Notification represents an event a user is notified of.
+type Notification struct {
+	// ID is the unique identifier of the notification.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the event happened.
+	CreatedAt time.Time `json:"created_at"`
+	// Type is the kind of event, e.g. "mention" or "follow".
+	Type string `json:"type"`
+	// ActorID is the identifier of the user who caused the event, if any.
+	ActorID *uuid.UUID `json:"actor_id,omitempty"`
+	// ChirpID is the identifier of the chirp the event is about, if any.
+	ChirpID *uuid.UUID `json:"chirp_id,omitempty"`
+	// Read reports whether the notification was marked as read.
+	Read bool `json:"read"`
+}
+
+// NotificationPreference tells whether a user receives a type of notification.
+type NotificationPreference struct {
+	// Type is the kind of notification.
+	Type string `json:"type"`
+	// Description explains the type.
+	Description string `json:"description"`
+	// Enabled reports whether the user receives notifications of this type.
+	Enabled bool `json:"enabled"`
+}
+
+// notificationPageResponse is a page of a user's notifications.
+type notificationPageResponse struct {
+	// Notifications is the current page, most recent first.
+	Notifications []Notification `json:"notifications"`
+	// UnreadCount is the total number of unread notifications.
+	UnreadCount int64 `json:"unread_count"`
+	// NextCursor fetches the next page when passed as the "cursor" query parameter.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// handlerNotificationsList lists the authenticated user's notifications, most recent first,
+// with cursor pagination, along with their unread count.
+func (cfg *apiConfig) handlerNotificationsList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbNotifications, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+		return
+	}
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count notifications", err)
+		return
+	}
+
+	resp := notificationPageResponse{Notifications: []Notification{}, UnreadCount: unread}
+	for _, n := range dbNotifications {
+		resp.Notifications = append(resp.Notifications, notificationFromDB(n))
+	}
+	if len(dbNotifications) > 0 {
+		last := dbNotifications[len(dbNotifications)-1]
+		resp.NextCursor = p.nextCursor(len(dbNotifications), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// handlerNotificationsRead marks the authenticated user's notifications as read:
+// the ones listed in "ids", or all of them when no IDs are given.
+func (cfg *apiConfig) handlerNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+
+	if len(params.IDs) == 0 {
+		_, err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	} else {
+		_, err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't mark notifications as read", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerNotificationPreferencesGet lists every notification type and whether the authenticated user receives it.
+func (cfg *apiConfig) handlerNotificationPreferencesGet(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	cfg.respondWithNotificationPreferences(w, r, userID)
+}
+
+// handlerNotificationPreferencesUpdate turns notification types on or off for the authenticated user.
+// The body maps type names to their new state, e.g. {"follow": false}; types left out are unchanged.
+func (cfg *apiConfig) handlerNotificationPreferencesUpdate(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := map[notificationType]bool{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	for t := range params {
+		if _, ok := notificationTypes[t]; !ok {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid notification type: "+string(t), nil)
+			return
+		}
+	}
+
+	for t, enabled := range params {
+		err = cfg.db.UpsertNotificationPreference(r.Context(), database.UpsertNotificationPreferenceParams{
+			UserID:  userID,
+			Type:    string(t),
+			Enabled: enabled,
+		})
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't update preferences", err)
+			return
+		}
+	}
+
+	cfg.respondWithNotificationPreferences(w, r, userID)
+}
+
+// respondWithNotificationPreferences sends a user's preference for every registered notification type,
+// falling back to the type's default when the user never set one.
+func (cfg *apiConfig) respondWithNotificationPreferences(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	dbPreferences, err := cfg.db.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve preferences", err)
+		return
+	}
+	enabled := make(map[notificationType]bool, len(dbPreferences))
+	for _, p := range dbPreferences {
+		enabled[notificationType(p.Type)] = p.Enabled
+	}
+
+	preferences := []NotificationPreference{}
+	for t, info := range notificationTypes {
+		isEnabled, ok := enabled[t]
+		if !ok {
+			isEnabled = info.EnabledByDefault
+		}
+		preferences = append(preferences, NotificationPreference{
+			Type:        string(t),
+			Description: info.Description,
+			Enabled:     isEnabled,
+		})
+	}
+	slices.SortFunc(preferences, func(a, b NotificationPreference) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	respondWithJSON(w, r, http.StatusOK, preferences)
+}
+
+// notificationFromDB converts a database notification into its JSON representation.
+func notificationFromDB(n database.Notification) Notification {
+	notification := Notification{
+		ID:        n.ID,
+		CreatedAt: n.CreatedAt,
+		Type:      n.Type,
+		Read:      n.ReadAt.Valid,
+	}
+	if n.ActorID.Valid {
+		notification.ActorID = &n.ActorID.UUID
+	}
+	if n.ChirpID.Valid {
+		notification.ChirpID = &n.ChirpID.UUID
+	}
+	return notification
+}
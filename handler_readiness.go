@@ -3,9 +3,14 @@ package main
 import "net/http"
 
 // handlerReadiness is a simple health-check endpoint.
-// It returns a plain text "OK" message along with an HTTP 200 status.
-func handlerReadiness(w http.ResponseWriter, r *http.Request) {
+// It returns a plain text "OK" message along with an HTTP 200 status,
+// or 503 once the server is shutting down so that load balancers stop sending it traffic.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(http.StatusText(http.StatusOK)))
+	status := http.StatusOK
+	if cfg.draining.Load() {
+		status = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(http.StatusText(status)))
 }
@@ -16,13 +16,17 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Couldn't find token", err)
+		respondWithError(w, r, http.StatusBadRequest, "Couldn't find token", err)
 		return
 	}
 
 	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't get user for refresh token", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't get user for refresh token", err)
+		return
+	}
+	if isSuspended(user) {
+		respondWithSuspended(w, r, user)
 		return
 	}
 
@@ -32,11 +36,11 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		time.Hour,
 	)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate token", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate token", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response{
+	respondWithJSON(w, r, http.StatusOK, response{
 		Token: accessToken,
 	})
 }
@@ -47,13 +51,13 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Couldn't find token", err)
+		respondWithError(w, r, http.StatusBadRequest, "Couldn't find token", err)
 		return
 	}
 
 	_, err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't revoke session", err)
 		return
 	}
 
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxReportCommentLength is the maximum number of characters of the comment attached to a report.
+const maxReportCommentLength = 1000
+
+// Report statuses, as stored in the reports table.
+const (
+	reportStatusOpen      = "open"
+	reportStatusResolved  = "resolved"
+	reportStatusDismissed = "dismissed"
+)
+
+// reportReasons are the categories a report must pick from.
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual":         true,
+	"misinformation": true,
+	"other":          true,
+}
+
+// Report represents a user's complaint about a chirp or another user, reviewed by moderators.
+type Report struct {
+	// ID is the unique identifier of the report.
+	ID uuid.UUID `json:"id"`
+	// CreatedAt is the timestamp when the report was filed.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the timestamp when the report was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+	// ReporterID is the identifier of the user who filed the report.
+	ReporterID uuid.UUID `json:"reporter_id"`
+	// UserID is the identifier of the reported user, or of the author of the reported chirp.
+	UserID uuid.UUID `json:"user_id"`
+	// ChirpID is the identifier of the reported chirp, if the report is about a chirp.
+	ChirpID *uuid.UUID `json:"chirp_id,omitempty"`
+	// Reason is the category of the report.
+	Reason string `json:"reason"`
+	// Comment gives moderators more context.
+	Comment string `json:"comment"`
+	// Status is "open" until a moderator acts on the report, then "resolved" or "dismissed".
+	Status string `json:"status"`
+	// ResolvedAt is the timestamp when a moderator closed the report.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// reportFromDB converts a database report into its JSON representation.
+func reportFromDB(r database.Report) Report {
+	report := Report{
+		ID:         r.ID,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+		ReporterID: r.ReporterID,
+		UserID:     r.UserID,
+		Reason:     r.Reason,
+		Comment:    r.Comment,
+		Status:     r.Status,
+		ResolvedAt: nullTimePtr(r.ResolvedAt),
+	}
+	if r.ChirpID.Valid {
+		report.ChirpID = &r.ChirpID.UUID
+	}
+	return report
+}
+
+// reportParameters is the body of a report request.
+type reportParameters struct {
+	// Reason is one of reportReasons.
+	Reason string `json:"reason"`
+	// Comment is optional.
+	Comment string `json:"comment"`
+}
+
+// validate checks the reason and comment of a report.
+func (p reportParameters) validate() error {
+	if !reportReasons[p.Reason] {
+		return errors.New("Invalid reason, must be 'spam', 'harassment', 'hate', 'violence', 'sexual', " +
+			"'misinformation' or 'other'")
+	}
+	if utf8.RuneCountInString(p.Comment) > maxReportCommentLength {
+		return errors.New("Comment is too long")
+	}
+	return nil
+}
+
+// handlerChirpsReport files a report about a chirp the authenticated user can see.
+// Reporting a rechirp reports the original chirp. A user can only have one open report per chirp.
+func (cfg *apiConfig) handlerChirpsReport(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := reportParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	err = params.validate()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirp, err := cfg.getShareTarget(r.Context(), chirpID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirp", err)
+		return
+	}
+	if chirp.UserID == userID {
+		respondWithError(w, r, http.StatusBadRequest, "Users can't report their own chirps", nil)
+		return
+	}
+
+	cfg.createReport(w, r, database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     chirp.UserID,
+		ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		Reason:     params.Reason,
+		Comment:    params.Comment,
+	})
+}
+
+// handlerUsersReport files a report about another user. A user can only have one open report per user.
+func (cfg *apiConfig) handlerUsersReport(w http.ResponseWriter, r *http.Request) {
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := reportParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+	err = params.validate()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if targetID == userID {
+		respondWithError(w, r, http.StatusBadRequest, "Users can't report themselves", nil)
+		return
+	}
+	_, err = cfg.db.GetUserByID(r.Context(), targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return
+	}
+
+	cfg.createReport(w, r, database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     targetID,
+		Reason:     params.Reason,
+		Comment:    params.Comment,
+	})
+}
+
+// createReport stores a report and responds with it, or with 409 if the reporter already has an open
+// report about the same target.
+func (cfg *apiConfig) createReport(w http.ResponseWriter, r *http.Request, params database.CreateReportParams) {
+	report, err := cfg.db.CreateReport(r.Context(), params)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		respondWithError(w, r, http.StatusConflict, "You already reported this", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create report", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusCreated, reportFromDB(report))
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// streamHeartbeatInterval is how often an idle stream sends a comment to keep the connection open.
+	streamHeartbeatInterval = 15 * time.Second
+	// streamReplayBatchSize is the number of events loaded at once when a client resumes a stream.
+	streamReplayBatchSize = 100
+	// streamWriteWait is the time allowed to write an event to a stream.
+	streamWriteWait = 10 * time.Second
+)
+
+// chirpStreamFilter selects the chirp events a stream subscriber is interested in.
+type chirpStreamFilter struct {
+	// AuthorID restricts the stream to the chirps of one user.
+	AuthorID uuid.NullUUID
+	// Timeline restricts the stream to the chirps of the users the viewer follows.
+	Timeline bool
+}
+
+// chirpStream writes the chirp events a viewer may see to a Server-Sent Events response.
+type chirpStream struct {
+	cfg    *apiConfig
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	viewer uuid.NullUUID
+	filter chirpStreamFilter
+	// lastID is the ID of the last event handled, sent or not. The record_chirp_event trigger commits
+	// events in the order of their IDs, so no event with a lower ID can show up later.
+	lastID int64
+}
+
+// handlerStreamChirps streams newly published and deleted chirps with Server-Sent Events.
+// The "author_id" query parameter restricts the stream to one author, and "timeline=true" to the accounts
+// the caller follows. Clients resume after a disconnection with the Last-Event-ID header (or the
+// "last_event_id" query parameter), and idle streams send a heartbeat comment.
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	viewer, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	filter := chirpStreamFilter{Timeline: r.URL.Query().Get("timeline") == "true"}
+	if filter.Timeline && !viewer.Valid {
+		respondWithError(w, r, http.StatusUnauthorized, "The timeline stream requires authentication", nil)
+		return
+	}
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		authorID, err := uuid.Parse(s)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+		filter.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid last event ID", err)
+			return
+		}
+	}
+
+	// Subscribe before replaying so that no event falls between the replay and the live stream.
+	sub := cfg.events.Subscribe()
+	defer cfg.events.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &chirpStream{
+		cfg:    cfg,
+		w:      w,
+		rc:     http.NewResponseController(w),
+		viewer: viewer,
+		filter: filter,
+		lastID: lastID,
+	}
+	// Streams outlive the server's read and write timeouts: the read deadline is lifted so that it doesn't
+	// end the request, and every write sets its own deadline instead.
+	err = stream.rc.SetReadDeadline(time.Time{})
+	if err == nil {
+		err = stream.write(nil)
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "Couldn't start chirp stream", "error", err)
+		return
+	}
+
+	ctx := r.Context()
+	if lastEventID != "" {
+		err = stream.replay(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't replay chirp events", "error", err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case hubEvent, ok := <-sub.events:
+			if !ok {
+				// The hub dropped the subscriber: it lagged behind or the server is shutting down.
+				return
+			}
+			// The hub also announces notifications, which the chirp stream doesn't carry.
+			if hubEvent.NotificationID.Valid || hubEvent.ChirpEventID <= stream.lastID {
+				continue
+			}
+			event, err := cfg.db.GetChirpEvent(ctx, hubEvent.ChirpEventID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Couldn't get chirp event", "event_id", hubEvent.ChirpEventID, "error", err)
+				continue
+			}
+			err = stream.send(ctx, event)
+			if err != nil {
+				slog.WarnContext(ctx, "Couldn't send chirp event", "error", err)
+				return
+			}
+		case <-heartbeat.C:
+			err := stream.write([]byte(": heartbeat\n\n"))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replay sends the events that happened after lastID, oldest first.
+func (s *chirpStream) replay(ctx context.Context) error {
+	for {
+		events, err := s.cfg.db.GetChirpEventsAfter(ctx, database.GetChirpEventsAfterParams{
+			AfterID: s.lastID,
+			Limit:   streamReplayBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			err = s.send(ctx, event)
+			if err != nil {
+				return err
+			}
+		}
+		if len(events) < streamReplayBatchSize {
+			return nil
+		}
+	}
+}
+
+// send writes an event to the stream if it matches the filter and the viewer may see its chirp.
+func (s *chirpStream) send(ctx context.Context, event database.ChirpEvent) error {
+	s.lastID = event.ID
+
+	if s.filter.AuthorID.Valid && event.UserID != s.filter.AuthorID.UUID {
+		return nil
+	}
+	access, err := s.cfg.db.GetChirpEventAccess(ctx, database.GetChirpEventAccessParams{
+		ViewerID: s.viewer,
+		ID:       event.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !access.Visible || (s.filter.Timeline && !access.Followed) {
+		return nil
+	}
+
+	var payload any
+	switch event.Type {
+	case "created":
+		dbChirp, err := s.cfg.db.GetChirp(ctx, database.GetChirpParams{
+			ID:       event.ChirpID,
+			ViewerID: s.viewer,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// The chirp was deleted since; its deletion event follows.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		chirp, err := s.cfg.renderChirp(ctx, s.viewer, dbChirp)
+		if errors.Is(err, errRechirpUnavailable) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		payload = chirp
+	case "deleted":
+		payload = struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: event.ChirpID}
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Appendf(nil, "id: %d\nevent: chirp.%s\ndata: %s\n\n", event.ID, event.Type, data))
+}
+
+// write sends p to the client and flushes it, failing if the client doesn't take it within streamWriteWait.
+func (s *chirpStream) write(p []byte) error {
+	err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(p)
+	if err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/entities"
+)
+
+// handlerTagsChirps lists the chirps using a hashtag, most recent first, with cursor pagination.
+// The tag is matched case-insensitively and may be given with or without its leading '#'.
+func (cfg *apiConfig) handlerTagsChirps(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid tag", nil)
+		return
+	}
+	viewer, err := cfg.viewerFromRequest(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+		Name:            tag,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		ViewerID:        viewer,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+	cfg.respondWithChirpPage(w, r, viewer, p, dbChirps)
+}
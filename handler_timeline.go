@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// chirpPageResponse is a page of chirps returned by cursor-paginated endpoints.
+type chirpPageResponse struct {
+	// Chirps is the current page, most recent first.
+	Chirps []Chirp `json:"chirps"`
+	// NextCursor fetches the next page when passed as the "cursor" query parameter.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// handlerTimeline returns the authenticated user's home timeline:
+// chirps from the accounts they follow, most recent first, with cursor pagination.
+func (cfg *apiConfig) handlerTimeline(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetTimeline(r.Context(), database.GetTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve timeline", err)
+		return
+	}
+
+	cfg.respondWithChirpPage(w, r, asViewer(userID), p, dbChirps)
+}
+
+// respondWithChirpPage renders a page of chirps for a viewer and sends it along with the cursor of the next page.
+func (cfg *apiConfig) respondWithChirpPage(
+	w http.ResponseWriter,
+	r *http.Request,
+	viewer uuid.NullUUID,
+	p page,
+	dbChirps []database.Chirp,
+) {
+	chirps, err := cfg.renderChirps(r.Context(), viewer, dbChirps)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't render chirps", err)
+		return
+	}
+
+	resp := chirpPageResponse{Chirps: chirps}
+	if len(dbChirps) > 0 {
+		last := dbChirps[len(dbChirps)-1]
+		resp.NextCursor = p.nextCursor(len(dbChirps), last.CreatedAt, last.ID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
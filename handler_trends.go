@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/trends"
+)
+
+// defaultTrendsLimit is the number of trends returned when the client does not ask for a limit.
+const defaultTrendsLimit = 10
+
+// Trend is a hashtag whose use is growing.
+type Trend struct {
+	// Hashtag is the trending hashtag, without its '#'.
+	Hashtag string `json:"hashtag"`
+	// Score measures how much faster than usual the hashtag is being used.
+	Score float64 `json:"score"`
+	// Uses is the number of public chirps using the hashtag in the trends window.
+	Uses int64 `json:"uses"`
+	// Authors is the number of users who used the hashtag in the trends window.
+	Authors int64 `json:"authors"`
+}
+
+// handlerTrends returns the trending hashtags, highest score first, from the cache filled by the trendsJob.
+// The optional "language" query parameter scopes them to chirps in that language, and "limit" caps their
+// number (at most trendsCacheSize).
+func (cfg *apiConfig) handlerTrends(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Language   string     `json:"language,omitempty"`
+		ComputedAt *time.Time `json:"computed_at"`
+		Trends     []Trend    `json:"trends"`
+	}
+
+	language, err := trends.NormalizeLanguage(r.URL.Query().Get("language"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	limit := defaultTrendsLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid limit, must be a positive integer", err)
+			return
+		}
+		limit = min(limit, trendsCacheSize)
+	}
+
+	dbTrends, err := cfg.db.GetTrends(r.Context(), database.GetTrendsParams{
+		Language: language,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve trends", err)
+		return
+	}
+
+	resp := response{Language: language, Trends: make([]Trend, 0, len(dbTrends))}
+	for _, t := range dbTrends {
+		resp.ComputedAt = &t.ComputedAt
+		resp.Trends = append(resp.Trends, Trend{
+			Hashtag: t.Hashtag,
+			Score:   t.Score,
+			Uses:    t.Uses,
+			Authors: t.Authors,
+		})
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
@@ -22,7 +22,7 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 
 	accessToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find token", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find token", err)
 		return
 	}
 
@@ -30,19 +30,19 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 	params := parameters{}
 	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithDecodeError(w, r, err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(accessToken, cfg.jwtSecret)
+	userID, err := cfg.validateJWT(r.Context(), accessToken)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
 		return
 	}
 
 	newHashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't hash password", err)
 		return
 	}
 
@@ -52,16 +52,9 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 		HashedPassword: newHashedPassword,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update user", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response{
-		User{
-			ID:          dbUser.ID,
-			Email:       dbUser.Email,
-			IsChirpyRed: dbUser.IsChirpyRed,
-			CreatedAt:   dbUser.CreatedAt,
-			UpdatedAt:   dbUser.UpdatedAt,
-		}})
+	respondWithJSON(w, r, http.StatusOK, response{cfg.userFromDB(dbUser)})
 }
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/alnah/go-httpserver/internal/auth"
 	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/profile"
 	"github.com/google/uuid"
 )
 
@@ -15,8 +17,22 @@ import (
 type User struct {
 	// ID is the unique identifier for the user.
 	ID uuid.UUID `json:"id"`
-	// Email is the user's email address.
+	// Email is the user's email address. It is only shown to the user themselves.
 	Email string `json:"email"`
+	// Handle is the user's unique handle, without its '@'.
+	Handle string `json:"handle"`
+	// DisplayName is the name the user chose to display, if any.
+	DisplayName string `json:"display_name"`
+	// Bio is a short description of the user.
+	Bio string `json:"bio"`
+	// Location is where the user says they are.
+	Location string `json:"location"`
+	// Website is the URL of the user's website.
+	Website string `json:"website"`
+	// Avatar holds the URLs of the user's avatar in each size, or null if they haven't uploaded one.
+	Avatar ProfileImage `json:"avatar"`
+	// Banner holds the URLs of the user's profile banner in each size, or null if they haven't uploaded one.
+	Banner ProfileImage `json:"banner"`
 	// IsChirpyRed indicates whether the user has an upgraded membership.
 	IsChirpyRed bool `json:"is_chirpy_red"`
 	// CreatedAt records when the user was created.
@@ -27,13 +43,33 @@ type User struct {
 	HashedPassword string `json:"-"` // For internal use only.
 }
 
+// userFromDB converts a database user into its JSON representation for the user themselves.
+func (cfg *apiConfig) userFromDB(u database.User) User {
+	return User{
+		ID:          u.ID,
+		Email:       u.Email,
+		Handle:      u.Handle,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		Location:    u.Location,
+		Website:     u.Website,
+		Avatar:      cfg.profileImage(u.AvatarKey, avatarSizes),
+		Banner:      cfg.profileImage(u.BannerKey, bannerSizes),
+		IsChirpyRed: u.IsChirpyRed,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+	}
+}
+
 // handlerUsersCreate creates a new user.
 // It decodes JSON parameters, hashes the password, creates the user in the database,
-// and returns the created user as JSON.
+// and returns the created user as JSON. The handle is optional; users without one get a generated handle
+// they can change later.
 func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Handle   string `json:"handle"`
 	}
 	type response struct {
 		User
@@ -43,32 +79,41 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 	params := parameters{}
 	err := decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithDecodeError(w, r, err)
 		return
 	}
 
+	handle := sql.NullString{}
+	if params.Handle != "" {
+		err = profile.ValidateHandle(params.Handle)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		handle = sql.NullString{String: params.Handle, Valid: true}
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't hash password", err)
 		return
 	}
 
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
+		Handle:         handle,
 	})
+	if isHandleTaken(err) {
+		respondWithError(w, r, http.StatusConflict, "Handle already taken", err)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create user", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't create user", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, response{
-		User: User{
-			ID:          user.ID,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-		},
+	respondWithJSON(w, r, http.StatusCreated, response{
+		User: cfg.userFromDB(user),
 	})
 }
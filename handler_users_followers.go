@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// Follow represents an entry of a user's follower or following list.
+type Follow struct {
+	// UserID is the identifier of the follower or followed user.
+	UserID uuid.UUID `json:"user_id"`
+	// FollowedAt is the timestamp when the follow was created.
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// followListResponse is a page of a follower or following list.
+type followListResponse struct {
+	// Users is the current page of the list, most recent follows first.
+	Users []Follow `json:"users"`
+	// Total is the total number of entries in the list.
+	Total int64 `json:"total"`
+	// NextCursor fetches the next page when passed as the "cursor" query parameter.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// handlerUsersFollowers lists the users following a given user, most recent first.
+// It supports cursor pagination and includes the total follower count.
+func (cfg *apiConfig) handlerUsersFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve followers", err)
+		return
+	}
+	total, err := cfg.db.CountFollowers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count followers", err)
+		return
+	}
+
+	resp := followListResponse{Users: []Follow{}, Total: total}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
+
+// handlerUsersFollowing lists the users a given user follows, most recent first.
+// It supports cursor pagination and includes the total following count.
+func (cfg *apiConfig) handlerUsersFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: p.CursorCreatedAt,
+		CursorID:        p.CursorID,
+		Limit:           p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve followed users", err)
+		return
+	}
+	total, err := cfg.db.CountFollowing(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count followed users", err)
+		return
+	}
+
+	resp := followListResponse{Users: []Follow{}, Total: total}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = p.nextCursor(len(rows), last.CreatedAt, last.UserID)
+	}
+	respondWithJSON(w, r, http.StatusOK, resp)
+}
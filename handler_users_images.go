@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/media"
+	"github.com/google/uuid"
+)
+
+var (
+	// avatarSizes are the square sizes every uploaded avatar is resized to.
+	avatarSizes = []media.Size{
+		{Name: "small", Width: 48, Height: 48},
+		{Name: "medium", Width: 128, Height: 128},
+		{Name: "large", Width: 400, Height: 400},
+	}
+	// bannerSizes are the 3:1 sizes every uploaded profile banner is resized to.
+	bannerSizes = []media.Size{
+		{Name: "small", Width: 600, Height: 200},
+		{Name: "large", Width: 1500, Height: 500},
+	}
+)
+
+// ProfileImage maps the name of each size of a profile image to its URL.
+type ProfileImage map[string]string
+
+// profileImageKind describes one of the images a user can show on their profile.
+type profileImageKind struct {
+	// name is used in storage keys and error messages.
+	name string
+	// sizes are the sizes the image is resized to.
+	sizes []media.Size
+	// setKey stores the new key of the user's image and returns the updated user and the previous key.
+	setKey func(ctx context.Context, db *database.Queries, userID uuid.UUID, key string) (database.User, string, error)
+}
+
+var (
+	// avatarKind is the user's avatar, shown next to their chirps.
+	avatarKind = profileImageKind{
+		name:   "avatar",
+		sizes:  avatarSizes,
+		setKey: setAvatarKey,
+	}
+	// bannerKind is the wide header image of the user's profile.
+	bannerKind = profileImageKind{
+		name:   "banner",
+		sizes:  bannerSizes,
+		setKey: setBannerKey,
+	}
+)
+
+// setAvatarKey stores the key of the user's avatar and returns the updated user and the previous key.
+func setAvatarKey(ctx context.Context, db *database.Queries, id uuid.UUID, key string) (database.User, string, error) {
+	row, err := db.SetUserAvatarKey(ctx, database.SetUserAvatarKeyParams{ID: id, AvatarKey: key})
+	return row.User, row.OldAvatarKey, err
+}
+
+// setBannerKey stores the key of the user's banner and returns the updated user and the previous key.
+func setBannerKey(ctx context.Context, db *database.Queries, id uuid.UUID, key string) (database.User, string, error) {
+	row, err := db.SetUserBannerKey(ctx, database.SetUserBannerKeyParams{ID: id, BannerKey: key})
+	return row.User, row.OldBannerKey, err
+}
+
+// handlerUsersAvatarUpdate replaces the authenticated user's avatar.
+func (cfg *apiConfig) handlerUsersAvatarUpdate(w http.ResponseWriter, r *http.Request) {
+	cfg.updateProfileImage(w, r, avatarKind)
+}
+
+// handlerUsersBannerUpdate replaces the authenticated user's profile banner.
+func (cfg *apiConfig) handlerUsersBannerUpdate(w http.ResponseWriter, r *http.Request) {
+	cfg.updateProfileImage(w, r, bannerKind)
+}
+
+// handlerUsersAvatarDelete removes the authenticated user's avatar.
+func (cfg *apiConfig) handlerUsersAvatarDelete(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, avatarKind)
+}
+
+// handlerUsersBannerDelete removes the authenticated user's profile banner.
+func (cfg *apiConfig) handlerUsersBannerDelete(w http.ResponseWriter, r *http.Request) {
+	cfg.deleteProfileImage(w, r, bannerKind)
+}
+
+// updateProfileImage expects a multipart form with a "file" part, like media uploads.
+// The image is validated, cropped and resized to every size of its kind, and stored in the blob store.
+// Once the user points at the new image, the previous one is deleted from the blob store.
+func (cfg *apiConfig) updateProfileImage(w http.ResponseWriter, r *http.Request, kind profileImageKind) {
+	type response struct {
+		User
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	data, _, err := readMediaUpload(r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, "Image is too large", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	variants, err := media.Resize(data, kind.sizes)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error(), err)
+		return
+	}
+	if errors.Is(err, media.ErrTooManyPixels) {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't process image", err)
+		return
+	}
+
+	key := kind.name + "s/" + uuid.New().String() + variants[0].Extension
+	stored := make([]string, 0, len(variants))
+	for _, v := range variants {
+		variantKey := profileImageKey(key, v.Name)
+		err = cfg.blobs.Put(r.Context(), variantKey, bytes.NewReader(v.Data), v.ContentType)
+		if err != nil {
+			cfg.deleteBlobs(stored...)
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't store "+kind.name, err)
+			return
+		}
+		stored = append(stored, variantKey)
+	}
+
+	user, oldKey, err := kind.setKey(r.Context(), cfg.db, userID, key)
+	if err != nil {
+		cfg.deleteBlobs(stored...)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusNotFound, "User not found", err)
+			return
+		}
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't save "+kind.name, err)
+		return
+	}
+	cfg.deleteBlobs(profileImageKeys(oldKey, kind.sizes)...)
+
+	respondWithJSON(w, r, http.StatusOK, response{cfg.userFromDB(user)})
+}
+
+// deleteProfileImage clears an image from the authenticated user's profile and deletes it from the blob store.
+func (cfg *apiConfig) deleteProfileImage(w http.ResponseWriter, r *http.Request, kind profileImageKind) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	_, oldKey, err := kind.setKey(r.Context(), cfg.db, userID, "")
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't delete "+kind.name, err)
+		return
+	}
+	cfg.deleteBlobs(profileImageKeys(oldKey, kind.sizes)...)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// profileImage returns the URLs of a profile image in each of its sizes, or nil if key is empty.
+func (cfg *apiConfig) profileImage(key string, sizes []media.Size) ProfileImage {
+	if key == "" {
+		return nil
+	}
+	urls := make(ProfileImage, len(sizes))
+	for _, size := range sizes {
+		urls[size.Name] = cfg.blobs.URL(profileImageKey(key, size.Name))
+	}
+	return urls
+}
+
+// profileImageKeys returns the keys of every resized copy of a profile image, or nil if key is empty.
+func profileImageKeys(key string, sizes []media.Size) []string {
+	if key == "" {
+		return nil
+	}
+	keys := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		keys = append(keys, profileImageKey(key, size.Name))
+	}
+	return keys
+}
+
+// profileImageKey returns the key of the copy of a profile image resized to the named size,
+// which is stored next to the key saved on the user, e.g. "avatars/<id>_small.png" for "avatars/<id>.png".
+func profileImageKey(key, size string) string {
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + size + ext
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/profile"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// handleConstraint is the unique index enforcing case-insensitive handles.
+const handleConstraint = "users_handle_lower_idx"
+
+// Profile is the public profile of a user. It never includes the email address.
+type Profile struct {
+	// ID is the unique identifier of the user.
+	ID uuid.UUID `json:"id"`
+	// Handle is the user's unique handle, without its '@'.
+	Handle string `json:"handle"`
+	// DisplayName is the name the user chose to display, if any.
+	DisplayName string `json:"display_name"`
+	// Bio is a short description of the user.
+	Bio string `json:"bio"`
+	// Location is where the user says they are.
+	Location string `json:"location"`
+	// Website is the URL of the user's website.
+	Website string `json:"website"`
+	// Avatar holds the URLs of the user's avatar in each size, or null if they haven't uploaded one.
+	Avatar ProfileImage `json:"avatar"`
+	// Banner holds the URLs of the user's profile banner in each size, or null if they haven't uploaded one.
+	Banner ProfileImage `json:"banner"`
+	// IsChirpyRed indicates whether the user has an upgraded membership.
+	IsChirpyRed bool `json:"is_chirpy_red"`
+	// CreatedAt records when the user joined.
+	CreatedAt time.Time `json:"created_at"`
+	// FollowersCount is the number of users following the user.
+	FollowersCount int64 `json:"followers_count"`
+	// FollowingCount is the number of users the user follows.
+	FollowingCount int64 `json:"following_count"`
+}
+
+// handlerUsersGet returns the public profile of a user, given their handle or ID.
+// Handles are matched case-insensitively.
+func (cfg *apiConfig) handlerUsersGet(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.getUserByHandleOrID(r.Context(), r.PathValue("handleOrID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, r, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't retrieve user", err)
+		return
+	}
+
+	followers, err := cfg.db.CountFollowers(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count followers", err)
+		return
+	}
+	following, err := cfg.db.CountFollowing(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't count following", err)
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, Profile{
+		ID:             user.ID,
+		Handle:         user.Handle,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		Avatar:         cfg.profileImage(user.AvatarKey, avatarSizes),
+		Banner:         cfg.profileImage(user.BannerKey, bannerSizes),
+		IsChirpyRed:    user.IsChirpyRed,
+		CreatedAt:      user.CreatedAt,
+		FollowersCount: followers,
+		FollowingCount: following,
+	})
+}
+
+// handlerUsersProfileUpdate edits the authenticated user's handle, display name, bio, location and website.
+// Fields left out of the body are unchanged, and empty strings clear the optional ones.
+func (cfg *apiConfig) handlerUsersProfileUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+	type response struct {
+		User
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithDecodeError(w, r, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "User not found", err)
+		return
+	}
+	update := database.UpdateUserProfileParams{
+		ID:          user.ID,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Location:    user.Location,
+		Website:     user.Website,
+	}
+	if params.Handle != nil {
+		err = profile.ValidateHandle(*params.Handle)
+		update.Handle = *params.Handle
+	}
+	if err == nil && params.DisplayName != nil {
+		update.DisplayName, err = profile.CleanText("display name", *params.DisplayName,
+			profile.MaxDisplayNameLength, false)
+	}
+	if err == nil && params.Bio != nil {
+		update.Bio, err = profile.CleanText("bio", *params.Bio, profile.MaxBioLength, true)
+	}
+	if err == nil && params.Location != nil {
+		update.Location, err = profile.CleanText("location", *params.Location, profile.MaxLocationLength, false)
+	}
+	if err == nil && params.Website != nil {
+		update.Website, err = profile.NormalizeWebsite(*params.Website)
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbUser, err := cfg.db.UpdateUserProfile(r.Context(), update)
+	if isHandleTaken(err) {
+		respondWithError(w, r, http.StatusConflict, "Handle already taken", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't update profile", err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, response{cfg.userFromDB(dbUser)})
+}
+
+// getUserByHandleOrID looks up a user by ID when s is a UUID, and by handle otherwise.
+// Handles can't be mistaken for IDs: they are too short to be a UUID written without hyphens.
+func (cfg *apiConfig) getUserByHandleOrID(ctx context.Context, s string) (database.User, error) {
+	if id, err := uuid.Parse(s); err == nil {
+		return cfg.db.GetUserByID(ctx, id)
+	}
+	return cfg.db.GetUserByHandle(ctx, s)
+}
+
+// isHandleTaken reports whether err is the violation of the uniqueness of handles.
+func isHandleTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == handleConstraint
+}
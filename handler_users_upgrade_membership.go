@@ -9,7 +9,8 @@ import (
 )
 
 // handlerUserUpgradeMembership upgrades a user's membership when a valid webhook event is received.
-// It validates the API key and event type, and then updates the user record in the database.
+// It validates the API key and event type, updates the user record in the database and notifies the user.
+// Events for users who are already members are acknowledged without effect.
 func (cfg *apiConfig) handlerUserUpgradeMembership(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Event string `json:"event"`
@@ -20,11 +21,11 @@ func (cfg *apiConfig) handlerUserUpgradeMembership(w http.ResponseWriter, r *htt
 
 	apiKey, err := auth.GetAPIKey(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find API key", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find API key", err)
 		return
 	}
 	if apiKey != cfg.polkaAPIKey {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate Polka API key", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate Polka API key", err)
 		return
 	}
 
@@ -32,7 +33,7 @@ func (cfg *apiConfig) handlerUserUpgradeMembership(w http.ResponseWriter, r *htt
 	decoder := json.NewDecoder(r.Body)
 	err = decoder.Decode(&params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithDecodeError(w, r, err)
 		return
 	}
 
@@ -41,9 +42,40 @@ func (cfg *apiConfig) handlerUserUpgradeMembership(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err = cfg.db.UpgradeUserMembership(r.Context(), params.Data.UserID)
+	user, err := cfg.db.GetUserByID(r.Context(), params.Data.UserID)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Couldn't upgrade user membership", err)
+		respondWithError(w, r, http.StatusNotFound, "Couldn't find user", err)
+		return
+	}
+	if user.IsChirpyRed {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't upgrade user membership", err)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.UpgradeUserMembership(r.Context(), params.Data.UserID)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Couldn't upgrade user membership", err)
+		return
+	}
+	err = notify(r.Context(), qtx, notification{
+		UserID: user.ID,
+		Type:   notificationMembership,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't notify user", err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't upgrade user membership", err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
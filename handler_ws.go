@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait is the time allowed to write a message to a WebSocket client.
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is the time allowed to read the next pong from a WebSocket client.
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod is how often WebSocket clients are pinged. It must be shorter than wsPongWait.
+	wsPingPeriod = 30 * time.Second
+	// wsMaxMessageSize is the largest message a WebSocket client may send, in bytes.
+	wsMaxMessageSize = 4 << 10
+	// wsMaxSubscriptions is the number of channels a WebSocket connection may subscribe to.
+	wsMaxSubscriptions = 20
+)
+
+const (
+	// wsChannelTimeline carries the chirps of the accounts the user follows.
+	wsChannelTimeline = "timeline"
+	// wsChannelNotifications carries the user's new notifications.
+	wsChannelNotifications = "notifications"
+	// wsChannelChirpPrefix, followed by a chirp ID, carries the quotes of that chirp and its deletion.
+	wsChannelChirpPrefix = "chirp:"
+)
+
+// wsUpgrader upgrades HTTP requests to WebSocket connections. Clients authenticate with a JWT rather than
+// cookies, so cross-origin connections cannot act on a user's behalf and any origin is accepted.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// wsClientMessage is a message sent by a WebSocket client.
+type wsClientMessage struct {
+	// Type is "subscribe" or "unsubscribe".
+	Type string `json:"type"`
+	// Channel is the channel to subscribe to or unsubscribe from.
+	Channel string `json:"channel"`
+}
+
+// wsServerMessage is a message sent to a WebSocket client.
+type wsServerMessage struct {
+	// Type is the kind of message, e.g. "chirp.created", "notification.created", "subscribed" or "error".
+	Type string `json:"type"`
+	// Channel is the channel the message relates to, if any.
+	Channel string `json:"channel,omitempty"`
+	// Data is the payload of the message, if any.
+	Data any `json:"data,omitempty"`
+}
+
+// wsClient is an authenticated WebSocket connection and the channels it subscribed to.
+type wsClient struct {
+	cfg      *apiConfig
+	conn     *websocket.Conn
+	userID   uuid.UUID
+	channels map[string]struct{}
+}
+
+// handlerWebSocket upgrades the request to a WebSocket connection delivering realtime events.
+// Clients authenticate with a JWT, either in the Authorization header or in the "access_token" query
+// parameter for clients that cannot set headers, then subscribe to channels: "timeline",
+// "notifications" or "chirp:<id>".
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+
+	// Upgrade responds to the client itself when it fails. net/http clears the server's deadlines on the
+	// hijacked connection, so read and send set their own before every read and write.
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Couldn't upgrade to WebSocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := cfg.events.Subscribe()
+	defer cfg.events.Unsubscribe(sub)
+
+	client := &wsClient{
+		cfg:      cfg,
+		conn:     conn,
+		userID:   userID,
+		channels: make(map[string]struct{}),
+	}
+	requests := make(chan []byte)
+	stop := make(chan struct{})
+	defer close(stop)
+	go client.read(requests, stop)
+
+	err = client.run(r.Context(), sub, requests)
+	if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		slog.WarnContext(r.Context(), "WebSocket connection ended", "error", err)
+	}
+}
+
+// read forwards the messages of the client to requests until the connection fails or stop is closed.
+// It closes requests on return. Pongs extend the read deadline, so a client that stops answering
+// pings is disconnected after wsPongWait.
+func (c *wsClient) read(requests chan<- []byte, stop <-chan struct{}) {
+	defer close(requests)
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case requests <- data:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// run is the only writer of the connection: it answers client requests, delivers hub events and pings
+// the client until the connection fails. When the hub drops the subscriber, because the client fell
+// behind or the server is shutting down, the connection is closed with "going away".
+func (c *wsClient) run(ctx context.Context, sub *hubSubscriber, requests <-chan []byte) error {
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case data, ok := <-requests:
+			if !ok {
+				return nil
+			}
+			err := c.handleRequest(data)
+			if err != nil {
+				return err
+			}
+		case event, ok := <-sub.events:
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "Reconnect to resume")
+				return c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+			}
+			err := c.handleEvent(ctx, event)
+			if err != nil {
+				return err
+			}
+		case <-ping.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleRequest applies a subscribe or unsubscribe request and acknowledges it.
+// Invalid requests are answered with an error message and leave the connection open.
+func (c *wsClient) handleRequest(data []byte) error {
+	var msg wsClientMessage
+	err := json.Unmarshal(data, &msg)
+	if err != nil {
+		return c.sendError("", "Invalid message")
+	}
+	channel, ok := parseWSChannel(msg.Channel)
+	if !ok {
+		return c.sendError(msg.Channel, "Invalid channel")
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		_, subscribed := c.channels[channel]
+		if !subscribed && len(c.channels) >= wsMaxSubscriptions {
+			return c.sendError(channel, fmt.Sprintf("Too many subscriptions (max %d)", wsMaxSubscriptions))
+		}
+		c.channels[channel] = struct{}{}
+		return c.send(wsServerMessage{Type: "subscribed", Channel: channel})
+	case "unsubscribe":
+		delete(c.channels, channel)
+		return c.send(wsServerMessage{Type: "unsubscribed", Channel: channel})
+	default:
+		return c.sendError(channel, "Invalid message type")
+	}
+}
+
+// handleEvent delivers a hub event on every subscribed channel it belongs to.
+// Events the client may not see, or that no longer exist, are skipped.
+func (c *wsClient) handleEvent(ctx context.Context, event hubEvent) error {
+	var msgs []wsServerMessage
+	var err error
+	if event.NotificationID.Valid {
+		msgs, err = c.notificationMessages(ctx, event)
+	} else {
+		msgs, err = c.chirpEventMessages(ctx, event.ChirpEventID)
+	}
+	if err != nil {
+		// Database errors only cost the client this event.
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(ctx, "Couldn't load event for WebSocket", "error", err)
+		}
+		return nil
+	}
+	for _, msg := range msgs {
+		err = c.send(msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notificationMessages returns the message for a new notification of the user on the notifications channel.
+func (c *wsClient) notificationMessages(ctx context.Context, event hubEvent) ([]wsServerMessage, error) {
+	if _, ok := c.channels[wsChannelNotifications]; !ok || event.UserID != c.userID {
+		return nil, nil
+	}
+	dbNotification, err := c.cfg.db.GetNotification(ctx, database.GetNotificationParams{
+		ID:     event.NotificationID.UUID,
+		UserID: c.userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []wsServerMessage{{
+		Type:    "notification.created",
+		Channel: wsChannelNotifications,
+		Data:    notificationFromDB(dbNotification),
+	}}, nil
+}
+
+// chirpEventMessages returns the messages for a chirp event: on the timeline channel if the user follows
+// its author, and on the channel of the chirp it is about, or that it quotes.
+func (c *wsClient) chirpEventMessages(ctx context.Context, id int64) ([]wsServerMessage, error) {
+	if !c.hasChirpChannels() {
+		return nil, nil
+	}
+	viewer := asViewer(c.userID)
+	access, err := c.cfg.db.GetChirpEventAccess(ctx, database.GetChirpEventAccessParams{
+		ViewerID: viewer,
+		ID:       id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !access.Visible {
+		return nil, nil
+	}
+	event, err := c.cfg.db.GetChirpEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []string
+	if _, ok := c.channels[wsChannelTimeline]; ok && access.Followed {
+		channels = append(channels, wsChannelTimeline)
+	}
+	var data any
+	switch event.Type {
+	case "created":
+		dbChirp, err := c.cfg.db.GetChirp(ctx, database.GetChirpParams{
+			ID:       event.ChirpID,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if dbChirp.QuoteOf.Valid {
+			channel := wsChannelChirpPrefix + dbChirp.QuoteOf.UUID.String()
+			if _, ok := c.channels[channel]; ok {
+				channels = append(channels, channel)
+			}
+		}
+		if len(channels) == 0 {
+			return nil, nil
+		}
+		chirp, err := c.cfg.renderChirp(ctx, viewer, dbChirp)
+		if err != nil {
+			return nil, err
+		}
+		data = chirp
+	case "deleted":
+		channel := wsChannelChirpPrefix + event.ChirpID.String()
+		if _, ok := c.channels[channel]; ok {
+			channels = append(channels, channel)
+		}
+		data = struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: event.ChirpID}
+	default:
+		return nil, nil
+	}
+
+	msgs := make([]wsServerMessage, 0, len(channels))
+	for _, channel := range channels {
+		msgs = append(msgs, wsServerMessage{Type: "chirp." + event.Type, Channel: channel, Data: data})
+	}
+	return msgs, nil
+}
+
+// hasChirpChannels reports whether the client subscribed to any channel carrying chirp events.
+func (c *wsClient) hasChirpChannels() bool {
+	for channel := range c.channels {
+		if channel != wsChannelNotifications {
+			return true
+		}
+	}
+	return false
+}
+
+// send writes a message to the client.
+func (c *wsClient) send(msg wsServerMessage) error {
+	err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err != nil {
+		return err
+	}
+	return c.conn.WriteJSON(msg)
+}
+
+// sendError writes an error message to the client.
+func (c *wsClient) sendError(channel, message string) error {
+	return c.send(wsServerMessage{
+		Type:    "error",
+		Channel: channel,
+		Data: struct {
+			Error string `json:"error"`
+		}{Error: message},
+	})
+}
+
+// parseWSChannel returns the canonical name of a channel clients may subscribe to,
+// and whether the channel is valid.
+func parseWSChannel(channel string) (string, bool) {
+	if channel == wsChannelTimeline || channel == wsChannelNotifications {
+		return channel, true
+	}
+	s, ok := strings.CutPrefix(channel, wsChannelChirpPrefix)
+	if !ok {
+		return "", false
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	return wsChannelChirpPrefix + id.String(), true
+}
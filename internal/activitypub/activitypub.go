@@ -0,0 +1,251 @@
+package activitypub
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentType is the media type of ActivityPub documents.
+	ContentType = "application/activity+json"
+	// JRDContentType is the media type of WebFinger responses.
+	JRDContentType = "application/jrd+json"
+	// PublicAddress is the special collection addressing an activity to everyone.
+	PublicAddress = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+// ActivityStreamsContext is the JSON-LD context of ActivityStreams documents.
+const ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+
+// securityContext is the JSON-LD context defining the publicKey property of actors.
+const securityContext = "https://w3id.org/security/v1"
+
+// Actor is an ActivityPub actor document.
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// Endpoints lists the optional endpoints of an actor.
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// PublicKey is the key an actor signs its requests with.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// NewPerson returns the actor document of a person, in the JSON-LD contexts remote servers expect.
+func NewPerson(id, username string, publicKeyPem string) Actor {
+	return Actor{
+		Context:           []string{ActivityStreamsContext, securityContext},
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: username,
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		PublicKey: PublicKey{
+			ID:           KeyID(id),
+			Owner:        id,
+			PublicKeyPem: publicKeyPem,
+		},
+	}
+}
+
+// KeyID returns the identifier of the main key of an actor.
+func KeyID(actorID string) string {
+	return actorID + "#main-key"
+}
+
+// DeliveryInbox returns the inbox activities for the actor should be delivered to,
+// preferring its shared inbox.
+func (a Actor) DeliveryInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+// Activity is an ActivityPub activity. Object is either the ID of an object or an embedded object:
+// when decoded from JSON, a string or a map[string]any.
+type Activity struct {
+	Context   any      `json:"@context,omitempty"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Object    any      `json:"object"`
+	To        []string `json:"to,omitempty"`
+	CC        []string `json:"cc,omitempty"`
+	Published string   `json:"published,omitempty"`
+}
+
+// NewActivity returns an activity in the ActivityStreams context.
+func NewActivity(id, activityType, actor string, object any) Activity {
+	return Activity{
+		Context: ActivityStreamsContext,
+		ID:      id,
+		Type:    activityType,
+		Actor:   actor,
+		Object:  object,
+	}
+}
+
+// ObjectID returns the ID of the object of the activity, whether it is embedded or not.
+func (a Activity) ObjectID() string {
+	switch object := a.Object.(type) {
+	case string:
+		return object
+	case map[string]any:
+		id, _ := object["id"].(string)
+		return id
+	case Activity:
+		return object.ID
+	case Note:
+		return object.ID
+	}
+	return ""
+}
+
+// ObjectType returns the type of the embedded object of the activity, or "" when it isn't embedded.
+func (a Activity) ObjectType() string {
+	switch object := a.Object.(type) {
+	case map[string]any:
+		t, _ := object["type"].(string)
+		return t
+	case Activity:
+		return object.Type
+	case Note:
+		return object.Type
+	}
+	return ""
+}
+
+// ObjectActivity returns the object of the activity decoded as an activity, e.g. the Follow of an Undo.
+func (a Activity) ObjectActivity() (Activity, bool) {
+	object, ok := a.Object.(map[string]any)
+	if !ok {
+		return Activity{}, false
+	}
+	inner := Activity{Object: object["object"]}
+	inner.ID, _ = object["id"].(string)
+	inner.Type, _ = object["type"].(string)
+	inner.Actor, _ = object["actor"].(string)
+	return inner, true
+}
+
+// Note is a short post, the ActivityPub object of a chirp.
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url,omitempty"`
+	Published    string   `json:"published"`
+	Updated      string   `json:"updated,omitempty"`
+	To           []string `json:"to"`
+	CC           []string `json:"cc,omitempty"`
+}
+
+// NewPublicNote returns a note by a person created with NewPerson, addressed to everyone and copied
+// to the person's followers. content is HTML.
+func NewPublicNote(id, actorID, content string, published time.Time) Note {
+	return Note{
+		ID:           id,
+		Type:         "Note",
+		AttributedTo: actorID,
+		Content:      content,
+		URL:          id,
+		Published:    published.UTC().Format(time.RFC3339),
+		To:           []string{PublicAddress},
+		CC:           []string{actorID + "/followers"},
+	}
+}
+
+// Tombstone is the object of the Delete activity of a note.
+type Tombstone struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// NewTombstone returns the tombstone of a deleted object.
+func NewTombstone(id string) Tombstone {
+	return Tombstone{ID: id, Type: "Tombstone"}
+}
+
+// OrderedCollection is a list of items, most recent first.
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// NewOrderedCollection returns a collection holding items, out of totalItems.
+func NewOrderedCollection(id string, totalItems int64, items []any) OrderedCollection {
+	return OrderedCollection{
+		Context:      ActivityStreamsContext,
+		ID:           id,
+		Type:         "OrderedCollection",
+		TotalItems:   totalItems,
+		OrderedItems: items,
+	}
+}
+
+// WebFinger is a WebFinger JSON Resource Descriptor (RFC 7033).
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink is a link of a WebFinger resource.
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// NewWebFinger returns the WebFinger resource pointing to an actor.
+func NewWebFinger(subject string, actor Actor) WebFinger {
+	return WebFinger{
+		Subject: subject,
+		Aliases: []string{actor.ID},
+		Links: []WebFingerLink{
+			{Rel: "self", Type: ContentType, Href: actor.ID},
+		},
+	}
+}
+
+// ErrInvalidAcct is returned when a WebFinger resource is not an "acct:" URI.
+var ErrInvalidAcct = errors.New("invalid acct URI")
+
+// ParseAcct splits an "acct:user@host" URI into its user and host.
+func ParseAcct(resource string) (string, string, error) {
+	acct, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return "", "", ErrInvalidAcct
+	}
+	acct = strings.TrimPrefix(acct, "@")
+	user, host, ok := strings.Cut(acct, "@")
+	if !ok || user == "" || host == "" || strings.Contains(host, "@") {
+		return "", "", ErrInvalidAcct
+	}
+	return user, strings.ToLower(host), nil
+}
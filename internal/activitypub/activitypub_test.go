@@ -0,0 +1,117 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAcct(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		wantUser string
+		wantHost string
+		wantErr  error
+	}{
+		{
+			name:     "Valid acct",
+			resource: "acct:alice@chirpy.example",
+			wantUser: "alice",
+			wantHost: "chirpy.example",
+		},
+		{
+			name:     "Leading at sign and uppercase host",
+			resource: "acct:@alice@Chirpy.Example",
+			wantUser: "alice",
+			wantHost: "chirpy.example",
+		},
+		{
+			name:     "Missing scheme",
+			resource: "alice@chirpy.example",
+			wantErr:  ErrInvalidAcct,
+		},
+		{
+			name:     "Missing host",
+			resource: "acct:alice",
+			wantErr:  ErrInvalidAcct,
+		},
+		{
+			name:     "Too many at signs",
+			resource: "acct:alice@chirpy.example@evil.example",
+			wantErr:  ErrInvalidAcct,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, host, err := ParseAcct(tt.resource)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseAcct() error = %v, want %v", err, tt.wantErr)
+			}
+			if user != tt.wantUser || host != tt.wantHost {
+				t.Errorf("ParseAcct() = %q, %q, want %q, %q", user, host, tt.wantUser, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestActivityObject(t *testing.T) {
+	tests := []struct {
+		name           string
+		json           string
+		wantObjectID   string
+		wantObjectType string
+	}{
+		{
+			name: "Object by reference",
+			json: `{"type":"Follow","actor":"https://remote.example/users/bob",` +
+				`"object":"https://chirpy.example/ap/users/1"}`,
+			wantObjectID: "https://chirpy.example/ap/users/1",
+		},
+		{
+			name: "Embedded object",
+			json: `{"type":"Undo","actor":"https://remote.example/users/bob","object":{` +
+				`"id":"https://remote.example/follows/1","type":"Follow","object":"https://chirpy.example/ap/users/1"}}`,
+			wantObjectID:   "https://remote.example/follows/1",
+			wantObjectType: "Follow",
+		},
+		{
+			name: "Missing object",
+			json: `{"type":"Follow","actor":"https://remote.example/users/bob"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var activity Activity
+			if err := json.Unmarshal([]byte(tt.json), &activity); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if got := activity.ObjectID(); got != tt.wantObjectID {
+				t.Errorf("ObjectID() = %q, want %q", got, tt.wantObjectID)
+			}
+			if got := activity.ObjectType(); got != tt.wantObjectType {
+				t.Errorf("ObjectType() = %q, want %q", got, tt.wantObjectType)
+			}
+		})
+	}
+}
+
+func TestObjectActivity(t *testing.T) {
+	var undo Activity
+	err := json.Unmarshal([]byte(`{"type":"Undo","actor":"https://remote.example/users/bob","object":{`+
+		`"id":"https://remote.example/follows/1","type":"Follow","actor":"https://remote.example/users/bob",`+
+		`"object":"https://chirpy.example/ap/users/1"}}`), &undo)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	follow, ok := undo.ObjectActivity()
+	if !ok {
+		t.Fatal("ObjectActivity() ok = false, want true")
+	}
+	if follow.Type != "Follow" || follow.Actor != undo.Actor || follow.ObjectID() != "https://chirpy.example/ap/users/1" {
+		t.Errorf("ObjectActivity() = %+v, want the undone Follow", follow)
+	}
+}
@@ -0,0 +1,173 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxResponseSize caps the size of the documents fetched from remote servers.
+	maxResponseSize = 1 << 20
+	// maxRedirects caps the number of redirects followed by a request.
+	maxRedirects = 3
+)
+
+// ErrNonPublicAddress is returned when a request would connect to a private, loopback, link-local
+// or otherwise non-public address.
+var ErrNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
+// nonPublicPrefixes are the special-purpose ranges that netip doesn't classify as private.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// Client fetches documents from and delivers activities to remote servers.
+type Client struct {
+	httpClient *http.Client
+	userAgent  string
+}
+
+// NewClient creates a client identifying itself with userAgent. Unless allowPrivate is set, the client
+// only connects to public addresses: the check runs on the resolved address of every connection,
+// redirects included, so that remote documents can't point the server at internal services.
+func NewClient(userAgent string, allowPrivate bool) *Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = refuseNonPublic
+		// A proxy would be dialed instead of the remote server, bypassing the check.
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+		userAgent: userAgent,
+	}
+}
+
+// IsPublicAddr reports whether ip is a public unicast address, i.e. not private, loopback,
+// link-local, multicast, unspecified or reserved for documentation and other special purposes.
+func IsPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseNonPublic is a net.Dialer Control function failing connections to non-public addresses.
+func refuseNonPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// DeliveryError is returned when a remote server rejects a request.
+type DeliveryError struct {
+	StatusCode int
+}
+
+// Error implements the error interface.
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("remote server responded with status %d", e.StatusCode)
+}
+
+// Permanent reports whether retrying the request is pointless: the server rejected it for a reason
+// other than being overloaded or timing out.
+func (e *DeliveryError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+// FetchActor retrieves the actor document at url.
+func (c *Client) FetchActor(ctx context.Context, url string) (Actor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Actor{}, err
+	}
+	req.Header.Set("Accept", ContentType)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Actor{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Actor{}, &DeliveryError{StatusCode: resp.StatusCode}
+	}
+
+	var actor Actor
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&actor)
+	if err != nil {
+		return Actor{}, err
+	}
+	if actor.ID != url || actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" {
+		return Actor{}, fmt.Errorf("invalid actor document at %s", url)
+	}
+	return actor, nil
+}
+
+// Deliver posts an activity to a remote inbox, signed with the key of its actor.
+// Rejections are reported as a *DeliveryError.
+func (c *Client) Deliver(ctx context.Context, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", c.userAgent)
+	err = SignRequest(req, activity, keyID, key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &DeliveryError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// fakeRemote is a remote ActivityPub server serving one actor and recording the activities
+// delivered to its inbox, after checking their signatures against the sender's published key.
+type fakeRemote struct {
+	server   *httptest.Server
+	client   *Client
+	received []Activity
+	status   int
+}
+
+func newFakeRemote(t *testing.T) *fakeRemote {
+	t.Helper()
+	remote := &fakeRemote{client: NewClient("chirpy-test", true), status: http.StatusAccepted}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/alice", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = json.NewEncoder(w).Encode(NewPerson(remote.server.URL+"/users/alice", "alice", "unused"))
+	})
+	mux.HandleFunc("POST /inbox", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var activity Activity
+		if err := json.Unmarshal(body, &activity); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		keyID, err := SignatureKeyID(r)
+		if err != nil || keyID != KeyID(activity.Actor) {
+			http.Error(w, "unknown key", http.StatusUnauthorized)
+			return
+		}
+		sender, err := remote.client.FetchActor(r.Context(), activity.Actor)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		publicKey, err := ParsePublicKey(sender.PublicKey.PublicKeyPem)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err := VerifyRequest(r, body, publicKey, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		remote.received = append(remote.received, activity)
+		w.WriteHeader(remote.status)
+	})
+	remote.server = httptest.NewServer(mux)
+	t.Cleanup(remote.server.Close)
+	return remote
+}
+
+func TestFetchActor(t *testing.T) {
+	remote := newFakeRemote(t)
+	client := NewClient("chirpy-test", true)
+
+	actor, err := client.FetchActor(context.Background(), remote.server.URL+"/users/alice")
+	if err != nil {
+		t.Fatalf("FetchActor() error = %v", err)
+	}
+	if actor.PreferredUsername != "alice" || actor.Inbox != remote.server.URL+"/users/alice/inbox" {
+		t.Errorf("FetchActor() = %+v, want alice's actor document", actor)
+	}
+
+	_, err = client.FetchActor(context.Background(), remote.server.URL+"/users/nobody")
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || deliveryErr.StatusCode != http.StatusNotFound {
+		t.Errorf("FetchActor() error = %v, want a 404 DeliveryError", err)
+	}
+}
+
+func TestFetchActorRefusesNonPublicAddresses(t *testing.T) {
+	remote := newFakeRemote(t)
+	client := NewClient("chirpy-test", false)
+
+	_, err := client.FetchActor(context.Background(), remote.server.URL+"/users/alice")
+	if !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("FetchActor() error = %v, want ErrNonPublicAddress", err)
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want bool
+	}{
+		{name: "Public IPv4", ip: "93.184.216.34", want: true},
+		{name: "Public IPv6", ip: "2606:4700::1111", want: true},
+		{name: "Loopback", ip: "127.0.0.1", want: false},
+		{name: "IPv6 loopback", ip: "::1", want: false},
+		{name: "Private", ip: "10.1.2.3", want: false},
+		{name: "Private 192.168", ip: "192.168.0.10", want: false},
+		{name: "Link-local metadata", ip: "169.254.169.254", want: false},
+		{name: "IPv6 link-local", ip: "fe80::1", want: false},
+		{name: "IPv6 unique local", ip: "fd00::1", want: false},
+		{name: "IPv4-mapped loopback", ip: "::ffff:127.0.0.1", want: false},
+		{name: "Carrier-grade NAT", ip: "100.64.0.1", want: false},
+		{name: "Unspecified", ip: "0.0.0.0", want: false},
+		{name: "Multicast", ip: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPublicAddr(netip.MustParseAddr(tt.ip)); got != tt.want {
+				t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	privatePem, publicPem, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	privateKey, err := ParsePrivateKey(privatePem)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+
+	// The local server publishes the sender's actor document with its public key.
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = json.NewEncoder(w).Encode(NewPerson("http://"+r.Host+r.URL.Path, "bob", publicPem))
+	}))
+	defer local.Close()
+	actorID := local.URL + "/ap/users/bob"
+
+	remote := newFakeRemote(t)
+	client := NewClient("chirpy-test", true)
+	activity, err := json.Marshal(NewActivity(actorID+"/follow/1", "Follow", actorID, remote.server.URL+"/users/alice"))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	err = client.Deliver(context.Background(), remote.server.URL+"/inbox", activity, KeyID(actorID), privateKey)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(remote.received) != 1 || remote.received[0].Type != "Follow" {
+		t.Fatalf("remote received %+v, want the Follow activity", remote.received)
+	}
+
+	remote.status = http.StatusGone
+	err = client.Deliver(context.Background(), remote.server.URL+"/inbox", activity, KeyID(actorID), privateKey)
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || !deliveryErr.Permanent() {
+		t.Errorf("Deliver() error = %v, want a permanent DeliveryError", err)
+	}
+}
+
+func TestDeliverRejectsForgedSignature(t *testing.T) {
+	_, publicPem, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	forgerPem, _, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	forgerKey, err := ParsePrivateKey(forgerPem)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = json.NewEncoder(w).Encode(NewPerson("http://"+r.Host+r.URL.Path, "bob", publicPem))
+	}))
+	defer local.Close()
+	actorID := local.URL + "/ap/users/bob"
+
+	remote := newFakeRemote(t)
+	activity, err := json.Marshal(NewActivity(actorID+"/follow/1", "Follow", actorID, remote.server.URL+"/users/alice"))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	err = NewClient("chirpy-test", true).Deliver(context.Background(), remote.server.URL+"/inbox", activity,
+		KeyID(actorID), forgerKey)
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || deliveryErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Deliver() error = %v, want a 401 DeliveryError", err)
+	}
+	if len(remote.received) != 0 {
+		t.Errorf("remote received %+v, want nothing", remote.received)
+	}
+}
@@ -0,0 +1,121 @@
+package activitypub
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyRequest(t *testing.T) {
+	privatePem, publicPem, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	privateKey, err := ParsePrivateKey(privatePem)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+	publicKey, err := ParsePublicKey(publicPem)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	_, otherPublicPem, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherPublicKey, err := ParsePublicKey(otherPublicPem)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	now := time.Now()
+	newSignedRequest := func(t *testing.T) *http.Request {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "https://chirpy.example/ap/users/1/inbox", nil)
+		err := SignRequest(req, body, "https://remote.example/users/bob#main-key", privateKey)
+		if err != nil {
+			t.Fatalf("SignRequest() error = %v", err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(req *http.Request) []byte
+		wantErr error
+	}{
+		{
+			name:    "Valid signature",
+			tamper:  func(*http.Request) []byte { return body },
+			wantErr: nil,
+		},
+		{
+			name:    "Tampered body",
+			tamper:  func(*http.Request) []byte { return []byte(`{"type":"Undo"}`) },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Tampered target",
+			tamper: func(req *http.Request) []byte {
+				req.URL.Path = "/ap/users/2/inbox"
+				return body
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Stale date",
+			tamper: func(req *http.Request) []byte {
+				req.Header.Set("Date", now.Add(-2*MaxClockSkew).UTC().Format(http.TimeFormat))
+				return body
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Date not signed",
+			tamper: func(req *http.Request) []byte {
+				sig := req.Header.Get("Signature")
+				req.Header.Set("Signature", strings.Replace(sig, " date", "", 1))
+				return body
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Missing signature",
+			tamper: func(req *http.Request) []byte {
+				req.Header.Del("Signature")
+				return body
+			},
+			wantErr: ErrMissingSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newSignedRequest(t)
+			gotBody := tt.tamper(req)
+			err := VerifyRequest(req, gotBody, publicKey, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("Wrong key", func(t *testing.T) {
+		req := newSignedRequest(t)
+		err := VerifyRequest(req, body, otherPublicKey, now)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifyRequest() error = %v, want %v", err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("Key ID", func(t *testing.T) {
+		keyID, err := SignatureKeyID(newSignedRequest(t))
+		if err != nil || keyID != "https://remote.example/users/bob#main-key" {
+			t.Errorf("SignatureKeyID() = %q, %v, want the signing key ID", keyID, err)
+		}
+	})
+}
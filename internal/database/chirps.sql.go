@@ -7,29 +7,88 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, quote_of, status, publish_at, visibility, language)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	QuoteOf    uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
+	Visibility string
+	Language   string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.QuoteOf,
+		arg.Status,
+		arg.PublishAt,
+		arg.Visibility,
+		arg.Language,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.RepostOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.Language,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    $2
+)
+ON CONFLICT (user_id, repost_of) WHERE repost_of IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language
+`
+
+type CreateRechirpParams struct {
+	UserID   uuid.UUID
+	RepostOf uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RepostOf)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +96,12 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.RepostOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.Language,
 	)
 	return i, err
 }
@@ -56,13 +121,93 @@ func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) error
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1
+AND repost_of = COALESCE(
+    (SELECT shared.repost_of FROM chirps AS shared WHERE shared.id = $2),
+    $2
+)
+`
+
+type DeleteRechirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled'
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE id = $1 AND status = 'published'
+AND chirp_visible_to(user_id, visibility, $2::uuid)
+`
+
+type GetChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirp(ctx context.Context, arg GetChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.RepostOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getChirpIncludingUnpublished = `-- name: GetChirpIncludingUnpublished :one
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
 WHERE id = $1
+AND (
+    (status = 'published' AND chirp_visible_to(user_id, visibility, $2::uuid))
+    OR user_id = $2::uuid
+    OR EXISTS (SELECT 1 FROM users WHERE users.id = $2::uuid AND users.is_admin)
+)
 `
 
-func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirp, id)
+type GetChirpIncludingUnpublishedParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpIncludingUnpublished(ctx context.Context, arg GetChirpIncludingUnpublishedParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpIncludingUnpublished, arg.ID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -70,17 +215,71 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.RepostOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.Language,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE status = 'published'
+AND chirp_in_feed_of(user_id, visibility, $1::uuid)
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) GetChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE id = ANY($1::uuid[]) AND status = 'published'
+AND chirp_visible_to(user_id, visibility, $2::uuid)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +293,12 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -109,13 +314,60 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE user_id = $1 AND status = 'published'
+AND chirp_visible_to(user_id, visibility, $2::uuid)
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type GetChirpsByUserIDParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByUserID(ctx context.Context, arg GetChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeldChirpsByUserID = `-- name: GetHeldChirpsByUserID :many
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE user_id = $1 AND status = 'held'
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) GetHeldChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getHeldChirpsByUserID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +381,12 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -142,3 +400,213 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const getRecentChirpBodies = `-- name: GetRecentChirpBodies :many
+SELECT body FROM chirps
+WHERE user_id = $1 AND created_at >= NOW() - INTERVAL '24 hours' AND repost_of IS NULL
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type GetRecentChirpBodiesParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetRecentChirpBodies(ctx context.Context, arg GetRecentChirpBodiesParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentChirpBodies, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			return nil, err
+		}
+		items = append(items, body)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirpsByUserID = `-- name: GetScheduledChirpsByUserID :many
+SELECT id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language FROM chirps
+WHERE user_id = $1 AND status = 'scheduled'
+ORDER BY publish_at ASC, id ASC
+`
+
+func (q *Queries) GetScheduledChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShareCounts = `-- name: GetShareCounts :many
+SELECT
+    original.id AS chirp_id,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.repost_of = original.id) AS rechirp_count,
+    (
+        SELECT COUNT(*) FROM chirps
+        WHERE chirps.quote_of = original.id AND chirps.status = 'published'
+    ) AS quote_count
+FROM chirps AS original
+WHERE original.id = ANY($1::uuid[])
+`
+
+type GetShareCountsRow struct {
+	ChirpID      uuid.UUID
+	RechirpCount int64
+	QuoteCount   int64
+}
+
+func (q *Queries) GetShareCounts(ctx context.Context, ids []uuid.UUID) ([]GetShareCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getShareCounts, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetShareCountsRow
+	for rows.Next() {
+		var i GetShareCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpCount, &i.QuoteCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled' AND publish_at <= $1
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE chirps
+SET status = 'published', created_at = NOW(), updated_at = NOW(), publish_at = NULL
+FROM due
+WHERE chirps.id = due.id
+RETURNING chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.repost_of, chirps.quote_of, chirps.status, chirps.publish_at, chirps.visibility, chirps.language
+`
+
+type PublishDueChirpsParams struct {
+	Now   time.Time
+	Limit int32
+}
+
+func (q *Queries) PublishDueChirps(ctx context.Context, arg PublishDueChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RepostOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateScheduledChirp = `-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = $3, publish_at = $4, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled'
+RETURNING id, created_at, updated_at, body, user_id, repost_of, quote_of, status, publish_at, visibility, language
+`
+
+type UpdateScheduledChirpParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledChirp,
+		arg.ID,
+		arg.UserID,
+		arg.Body,
+		arg.PublishAt,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.RepostOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.Language,
+	)
+	return i, err
+}
@@ -11,12 +11,193 @@ import (
 	"github.com/google/uuid"
 )
 
+type ActorKey struct {
+	UserID        uuid.UUID
+	CreatedAt     time.Time
+	PrivateKeyPem string
+	PublicKeyPem  string
+}
+
+type BannedTerm struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Term      string
+	Mode      string
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CollectionID uuid.NullUUID
+	CreatedAt    time.Time
+}
+
+type BookmarkCollection struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Chirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	RepostOf   uuid.NullUUID
+	QuoteOf    uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
+	Visibility string
+	Language   string
+}
+
+type ChirpEvent struct {
+	ID         int64
+	CreatedAt  time.Time
+	Type       string
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	Visibility string
+}
+
+type ChirpFlag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	Reason    string
+}
+
+type ChirpHashtag struct {
+	ChirpID    uuid.UUID
+	HashtagID  uuid.UUID
+	StartIndex int32
+	EndIndex   int32
+}
+
+type ChirpMention struct {
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	StartIndex int32
+	EndIndex   int32
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserAID   uuid.UUID
+	UserBID   uuid.UUID
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	LastReadAt     sql.NullTime
+}
+
+type FederationDelivery struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UserID        uuid.UUID
+	Inbox         string
+	Activity      string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
+}
+
+type MediaAttachment struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Position     int32
+	ContentType  string
+	Width        int32
+	Height       int32
+	SizeBytes    int32
+	StorageKey   string
+	ThumbnailKey string
+	AltText      string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type MessageFlag struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
+	MessageID uuid.UUID
+	Reason    string
+}
+
+type ModerationAction struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ModeratorID    uuid.NullUUID
+	ReportID       uuid.NullUUID
+	Action         string
+	UserID         uuid.UUID
+	ChirpID        uuid.NullUUID
+	Note           string
+	SuspendedUntil sql.NullTime
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Type      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Type      string
+	Enabled   bool
 	UpdatedAt time.Time
-	Body      string
+}
+
+type PostingEvent struct {
+	ID        uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +209,47 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type RemoteActor struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Uri          string
+	Inbox        string
+	SharedInbox  sql.NullString
+	PublicKeyID  string
+	PublicKeyPem string
+}
+
+type RemoteFollow struct {
+	UserID           uuid.UUID
+	RemoteActorID    uuid.UUID
+	CreatedAt        time.Time
+	FollowActivityID string
+}
+
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Comment    string
+	Status     string
+	ResolvedAt sql.NullTime
+}
+
+type Trend struct {
+	Language   string
+	Rank       int32
+	Hashtag    string
+	Score      float64
+	Uses       int64
+	Authors    int64
+	ComputedAt time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +257,13 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	IsAdmin        bool
+	Handle         string
+	DisplayName    string
+	Bio            string
+	Location       string
+	Website        string
+	AvatarKey      string
+	BannerKey      string
+	SuspendedUntil sql.NullTime
 }
@@ -7,51 +7,72 @@ package database
 
 import (
 	"context"
-	"time"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES (
-    gen_random_uuid(),
+WITH new_user AS (
+    SELECT gen_random_uuid() AS id
+)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
+SELECT
+    new_user.id,
     NOW(),
     NOW(),
     $1,
-    $2
-)
-RETURNING id, email, is_chirpy_red, created_at, updated_at
+    $2,
+    COALESCE($3::text, 'user_' || LEFT(REPLACE(new_user.id::text, '-', ''), 12))
+FROM new_user
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
-type CreateUserRow struct {
-	ID          uuid.UUID
-	Email       string
-	IsChirpyRed bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
-	var i CreateUserRow
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
+	var i User
 	err := row.Scan(
 		&i.ID,
-		&i.Email,
-		&i.IsChirpyRed,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
 	)
 	return i, err
 }
 
+const getUserAccountAge = `-- name: GetUserAccountAge :one
+SELECT EXTRACT(EPOCH FROM NOW() - created_at)::bigint AS age_seconds
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserAccountAge(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUserAccountAge, id)
+	var age_seconds int64
+	err := row.Scan(&age_seconds)
+	return age_seconds, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
 FROM users
 WHERE email = $1
 `
@@ -66,6 +87,204 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
+FROM users
+WHERE LOWER(handle) = LOWER($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
+	)
+	return i, err
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
+FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.IsAdmin,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Location,
+			&i.Website,
+			&i.AvatarKey,
+			&i.BannerKey,
+			&i.SuspendedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserAvatarKey = `-- name: SetUserAvatarKey :one
+UPDATE users
+SET avatar_key = $2,
+    updated_at = NOW()
+FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) AS old
+WHERE users.id = old.id
+RETURNING users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.is_admin, users.handle, users.display_name, users.bio, users.location, users.website, users.avatar_key, users.banner_key, users.suspended_until, old.avatar_key AS old_avatar_key
+`
+
+type SetUserAvatarKeyParams struct {
+	ID        uuid.UUID
+	AvatarKey string
+}
+
+type SetUserAvatarKeyRow struct {
+	User         User
+	OldAvatarKey string
+}
+
+func (q *Queries) SetUserAvatarKey(ctx context.Context, arg SetUserAvatarKeyParams) (SetUserAvatarKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatarKey, arg.ID, arg.AvatarKey)
+	var i SetUserAvatarKeyRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Email,
+		&i.User.HashedPassword,
+		&i.User.IsChirpyRed,
+		&i.User.IsAdmin,
+		&i.User.Handle,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.Location,
+		&i.User.Website,
+		&i.User.AvatarKey,
+		&i.User.BannerKey,
+		&i.User.SuspendedUntil,
+		&i.OldAvatarKey,
+	)
+	return i, err
+}
+
+const setUserBannerKey = `-- name: SetUserBannerKey :one
+UPDATE users
+SET banner_key = $2,
+    updated_at = NOW()
+FROM (SELECT id, banner_key FROM users WHERE id = $1 FOR UPDATE) AS old
+WHERE users.id = old.id
+RETURNING users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.is_admin, users.handle, users.display_name, users.bio, users.location, users.website, users.avatar_key, users.banner_key, users.suspended_until, old.banner_key AS old_banner_key
+`
+
+type SetUserBannerKeyParams struct {
+	ID        uuid.UUID
+	BannerKey string
+}
+
+type SetUserBannerKeyRow struct {
+	User         User
+	OldBannerKey string
+}
+
+func (q *Queries) SetUserBannerKey(ctx context.Context, arg SetUserBannerKeyParams) (SetUserBannerKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, setUserBannerKey, arg.ID, arg.BannerKey)
+	var i SetUserBannerKeyRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Email,
+		&i.User.HashedPassword,
+		&i.User.IsChirpyRed,
+		&i.User.IsAdmin,
+		&i.User.Handle,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.Location,
+		&i.User.Website,
+		&i.User.AvatarKey,
+		&i.User.BannerKey,
+		&i.User.SuspendedUntil,
+		&i.OldBannerKey,
 	)
 	return i, err
 }
@@ -76,7 +295,7 @@ SET email = $2,
     hashed_password = $3,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, is_chirpy_red, created_at, updated_at
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
 `
 
 type UpdateUserEmailAndPasswordByIDParams struct {
@@ -85,23 +304,76 @@ type UpdateUserEmailAndPasswordByIDParams struct {
 	HashedPassword string
 }
 
-type UpdateUserEmailAndPasswordByIDRow struct {
-	ID          uuid.UUID
-	Email       string
-	IsChirpyRed bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-func (q *Queries) UpdateUserEmailAndPasswordByID(ctx context.Context, arg UpdateUserEmailAndPasswordByIDParams) (UpdateUserEmailAndPasswordByIDRow, error) {
+func (q *Queries) UpdateUserEmailAndPasswordByID(ctx context.Context, arg UpdateUserEmailAndPasswordByIDParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUserEmailAndPasswordByID, arg.ID, arg.Email, arg.HashedPassword)
-	var i UpdateUserEmailAndPasswordByIDRow
+	var i User
 	err := row.Scan(
 		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 		&i.Email,
+		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2,
+    display_name = $3,
+    bio = $4,
+    location = $5,
+    website = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, handle, display_name, bio, location, website, avatar_key, banner_key, suspended_until
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Handle      string
+	DisplayName string
+	Bio         string
+	Location    string
+	Website     string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.Website,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.BannerKey,
+		&i.SuspendedUntil,
 	)
 	return i, err
 }
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Kind identifies the type of an entity found in a chirp body.
+type Kind string
+
+const (
+	// KindHashtag is a "#tag" entity.
+	KindHashtag Kind = "hashtag"
+	// KindMention is an "@user" entity.
+	KindMention Kind = "mention"
+)
+
+// maxEntityLength caps the number of characters of a hashtag or mention, sigil excluded.
+const maxEntityLength = 100
+
+// Entity is a hashtag or mention found in a chirp body.
+// Start and End are offsets in Unicode code points, End being exclusive,
+// and cover the entity including its sigil.
+type Entity struct {
+	Kind  Kind
+	Text  string
+	Start int
+	End   int
+}
+
+// Parse extracts the hashtags and mentions of a chirp body, in order of appearance.
+// A sigil only starts an entity at the beginning of the body or after a character that
+// can't be part of a word, so "a#b" and "user@example.com" contain no entity.
+// Hashtags are made of letters, marks, digits and underscores, and must contain at least
+// one letter. Mentions are made of the characters allowed in handles: ASCII letters, digits
+// and underscores. Text that continues past those characters, such as "@alice@example.com"
+// or "@jean-luc", can't be a handle and contains no mention.
+func Parse(body string) []Entity {
+	runes := []rune(body)
+	var found []Entity
+	for i := 0; i < len(runes); i++ {
+		var kind Kind
+		isPartRune := isWordRune
+		switch runes[i] {
+		case '#':
+			kind = KindHashtag
+		case '@':
+			kind = KindMention
+			isPartRune = isHandleRune
+		default:
+			continue
+		}
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isPartRune(runes[end]) {
+			end++
+		}
+
+		text := string(runes[i+1 : end])
+		if valid(kind, text) && (kind != KindMention || !continuesMention(runes[end:])) {
+			found = append(found, Entity{Kind: kind, Text: text, Start: i, End: end})
+		}
+		i = end - 1
+	}
+	return found
+}
+
+// NormalizeTag returns the canonical form of a hashtag, used to group
+// hashtags that only differ by case.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// valid reports whether the text following a sigil forms an entity.
+func valid(kind Kind, text string) bool {
+	length := len([]rune(text))
+	if length == 0 || length > maxEntityLength {
+		return false
+	}
+	if kind == KindHashtag {
+		return strings.IndexFunc(text, unicode.IsLetter) >= 0
+	}
+	return true
+}
+
+// isWordRune reports whether r can be part of a hashtag or mention.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isHandleRune reports whether r can be part of a mention, i.e. of a handle.
+func isHandleRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_'
+}
+
+// continuesMention reports whether the text following a mention makes it something other than
+// a handle: a word character, or '.', '-', '+' or '@' followed by a word character.
+func continuesMention(rest []rune) bool {
+	if len(rest) == 0 {
+		return false
+	}
+	if isWordRune(rest[0]) {
+		return true
+	}
+	switch rest[0] {
+	case '.', '-', '+', '@':
+		return len(rest) > 1 && isWordRune(rest[1])
+	}
+	return false
+}
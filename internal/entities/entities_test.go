@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "No entities",
+			body: "Hello Chirpy world!",
+			want: nil,
+		},
+		{
+			name: "Hashtag and mention",
+			body: "Hi @alice, see #golang!",
+			want: []Entity{
+				{Kind: KindMention, Text: "alice", Start: 3, End: 9},
+				{Kind: KindHashtag, Text: "golang", Start: 15, End: 22},
+			},
+		},
+		{
+			name: "Unicode hashtag offsets in code points",
+			body: "日本語 #東京 🎉 #café",
+			want: []Entity{
+				{Kind: KindHashtag, Text: "東京", Start: 4, End: 7},
+				{Kind: KindHashtag, Text: "café", Start: 10, End: 15},
+			},
+		},
+		{
+			name: "Email-style mention is ignored",
+			body: "ping @alice@example.com.",
+			want: nil,
+		},
+		{
+			name: "Characters handles can't contain",
+			body: "@jean-luc @bob.smith @carol+x @dané",
+			want: nil,
+		},
+		{
+			name: "Mention followed by punctuation",
+			body: "cc @dave_99. @Erin-",
+			want: []Entity{
+				{Kind: KindMention, Text: "dave_99", Start: 3, End: 11},
+				{Kind: KindMention, Text: "Erin", Start: 13, End: 18},
+			},
+		},
+		{
+			name: "Sigil inside a word",
+			body: "contact bob@example.com or a#b",
+			want: nil,
+		},
+		{
+			name: "Numeric hashtag is ignored",
+			body: "We're #1 #2024goals",
+			want: []Entity{
+				{Kind: KindHashtag, Text: "2024goals", Start: 9, End: 19},
+			},
+		},
+		{
+			name: "Lone sigils",
+			body: "# @ ## @@",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{name: "Lowercases", tag: "GoLang", want: "golang"},
+		{name: "Strips sigil", tag: "#Chirpy", want: "chirpy"},
+		{name: "Unicode", tag: "ÉTÉ", want: "été"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTag(tt.tag); got != tt.want {
+				t.Errorf("NormalizeTag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
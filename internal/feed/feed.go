@@ -0,0 +1,190 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// Feed is a list of entries that feed readers can subscribe to.
+type Feed struct {
+	// ID permanently and uniquely identifies the feed, e.g. its URL.
+	ID string
+	// Title is the human-readable name of the feed.
+	Title string
+	// Description is a sentence describing the feed.
+	Description string
+	// Link is the URL of the resource the feed is about.
+	Link string
+	// SelfLink is the URL the feed itself is served from.
+	SelfLink string
+	// Updated is the last time the feed changed.
+	Updated time.Time
+	// Entries are the items of the feed, most recent first.
+	Entries []Entry
+}
+
+// Entry is an item of a feed.
+type Entry struct {
+	// ID permanently and uniquely identifies the entry.
+	ID string
+	// Title is a short summary of the entry.
+	Title string
+	// Link is the permalink of the entry.
+	Link string
+	// Author is the name of the author of the entry.
+	Author string
+	// Content is the plain text of the entry.
+	Content string
+	// Published is the time the entry was first published.
+	Published time.Time
+	// Updated is the last time the entry changed.
+	Updated time.Time
+}
+
+// atomFeed is the XML representation of an Atom feed (RFC 4287).
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is the XML representation of an Atom link.
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// atomEntry is the XML representation of an Atom entry.
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+}
+
+// atomAuthor is the XML representation of an Atom person.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomContent is the XML representation of the content of an Atom entry.
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom encodes the feed as an Atom 1.0 document.
+func (f Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:    f.ID,
+		Title: f.Title,
+		Links: []atomLink{
+			{Rel: "alternate", Href: f.Link},
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfLink},
+		},
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+	}
+	for _, e := range f.Entries {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Link:      atomLink{Rel: "alternate", Href: e.Link},
+			Author:    atomAuthor{Name: e.Author},
+			Content:   atomContent{Type: "text", Body: e.Content},
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+		})
+	}
+	return marshal(doc)
+}
+
+// rssFeed is the XML representation of an RSS 2.0 document.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel is the XML representation of an RSS channel.
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem is the XML representation of an RSS item.
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Creator     string  `xml:"dc:creator"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// rssGUID is the XML representation of the unique identifier of an RSS item.
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS encodes the feed as an RSS 2.0 document. RSS has no update time for items,
+// so only their publication time is kept.
+func (f Feed) RSS() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			SelfLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfLink},
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{Value: e.ID},
+			Creator:     e.Author,
+			Description: e.Content,
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshal(doc)
+}
+
+// marshal encodes an XML document with its declaration.
+func marshal(doc any) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Summarize shortens text to a single line of at most maxLength characters, to title an entry.
+// Runs of whitespace are collapsed, and truncated text ends with an ellipsis.
+func Summarize(text string, maxLength int) string {
+	line := strings.Join(strings.Fields(text), " ")
+	runes := []rune(line)
+	if len(runes) <= maxLength {
+		return line
+	}
+	return strings.TrimSpace(string(runes[:maxLength-1])) + "…"
+}
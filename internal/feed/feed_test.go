@@ -0,0 +1,128 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return Feed{
+		ID:          "https://chirpy.example/users/42",
+		Title:       "Chirps by 42",
+		Description: "Public chirps by 42",
+		Link:        "https://chirpy.example/users/42",
+		SelfLink:    "https://chirpy.example/users/42/feed.atom",
+		Updated:     published.Add(time.Hour),
+		Entries: []Entry{
+			{
+				ID:        "urn:uuid:1",
+				Title:     "Hello <world> & friends",
+				Link:      "https://chirpy.example/api/chirps/1",
+				Author:    "42",
+				Content:   "Hello <world> & friends",
+				Published: published,
+				Updated:   published.Add(time.Hour),
+			},
+		},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	data, err := testFeed().Atom()
+	if err != nil {
+		t.Fatalf("Atom() error = %v", err)
+	}
+	doc := string(data)
+
+	for _, want := range []string{
+		xml.Header,
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<link rel="self" type="application/atom+xml" href="https://chirpy.example/users/42/feed.atom"></link>`,
+		`<updated>2025-03-01T13:00:00Z</updated>`,
+		`<published>2025-03-01T12:00:00Z</published>`,
+		`<content type="text">Hello &lt;world&gt; &amp; friends</content>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Atom() = %s, want it to contain %q", doc, want)
+		}
+	}
+	var parsed atomFeed
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("Atom() produced invalid XML: %v", err)
+	}
+	if len(parsed.Entries) != 1 || parsed.Entries[0].Content.Body != "Hello <world> & friends" {
+		t.Errorf("Atom() entries = %+v, want the escaped content to round-trip", parsed.Entries)
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := testFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS() error = %v", err)
+	}
+	doc := string(data)
+
+	for _, want := range []string{
+		`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">`,
+		`<atom:link rel="self" type="application/rss+xml" href="https://chirpy.example/users/42/feed.atom"></atom:link>`,
+		`<lastBuildDate>Sat, 01 Mar 2025 13:00:00 +0000</lastBuildDate>`,
+		`<guid isPermaLink="false">urn:uuid:1</guid>`,
+		`<pubDate>Sat, 01 Mar 2025 12:00:00 +0000</pubDate>`,
+		`<dc:creator>42</dc:creator>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("RSS() = %s, want it to contain %q", doc, want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "Short text",
+			text:      "Hello world",
+			maxLength: 20,
+			want:      "Hello world",
+		},
+		{
+			name:      "Whitespace collapsed",
+			text:      "Hello\n\n  world\t!",
+			maxLength: 20,
+			want:      "Hello world !",
+		},
+		{
+			name:      "Truncated with ellipsis",
+			text:      "The quick brown fox jumps",
+			maxLength: 11,
+			want:      "The quick…",
+		},
+		{
+			name:      "Counts characters, not bytes",
+			text:      "日本語のテキストです",
+			maxLength: 5,
+			want:      "日本語の…",
+		},
+		{
+			name:      "Exact length kept",
+			text:      "abcde",
+			maxLength: 5,
+			want:      "abcde",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.text, tt.maxLength); got != tt.want {
+				t.Errorf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package logging builds structured loggers and tags their records with the ID of the request being served.
+// It also tracks the user a request turns out to be made by, once the handler has authenticated them.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// maxRequestIDLength is the longest request ID accepted from a client.
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// requestUserKey is the context key of the requestUser.
+type requestUserKey struct{}
+
+// requestUser holds the ID of the user a request is made by. It is shared by every context derived from
+// the one WithRequestUser returned, so that the handler can fill it in for the middleware that logs the request.
+type requestUser struct {
+	mu sync.Mutex
+	id string
+}
+
+// NewHandler returns a handler writing records of at least the given level to w, as JSON when format is
+// "json" or as key=value pairs when it is "text". Records logged with a context carrying a request ID
+// get a "request_id" attribute.
+func NewHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "json":
+		return contextHandler{slog.NewJSONHandler(w, opts)}, nil
+	case "text":
+		return contextHandler{slog.NewTextHandler(w, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected \"json\" or \"text\"", format)
+	}
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it belongs to.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRequestUser returns a copy of ctx that can record the user a request is made by with SetUserID.
+func WithRequestUser(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestUserKey{}, &requestUser{})
+}
+
+// SetUserID records the ID of the user a request is made by, in the holder set up by WithRequestUser.
+// It does nothing when ctx has no holder.
+func SetUserID(ctx context.Context, id string) {
+	u, ok := ctx.Value(requestUserKey{}).(*requestUser)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.id = id
+}
+
+// UserID returns the ID recorded with SetUserID, or "" if there is none.
+func UserID(ctx context.Context) string {
+	u, ok := ctx.Value(requestUserKey{}).(*requestUser)
+	if !ok {
+		return ""
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.id
+}
+
+// ValidRequestID reports whether a request ID supplied by a client may be reused: it must be at most
+// 128 characters of letters, digits and "-", "_", ".", ":", so that it cannot forge log lines.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// contextHandler adds the request ID of the context to the records of the handler it wraps.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the request ID, if any, and passes the record on.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler that also adds attrs to every record.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler that nests the attributes of every record under name.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
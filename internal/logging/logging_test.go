@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		ctx       context.Context
+		want      string
+		wantError bool
+	}{
+		{
+			name:   "JSON with a request ID",
+			format: "json",
+			ctx:    WithRequestID(context.Background(), "abc-123"),
+			want:   `"msg":"hello","user":"saul","request_id":"abc-123"}`,
+		},
+		{
+			name:   "Text with a request ID",
+			format: "text",
+			ctx:    WithRequestID(context.Background(), "abc-123"),
+			want:   `msg=hello user=saul request_id=abc-123`,
+		},
+		{
+			name:   "Text without a request ID",
+			format: "text",
+			ctx:    context.Background(),
+			want:   `msg=hello user=saul` + "\n",
+		},
+		{
+			name:      "Unknown format",
+			format:    "xml",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler, err := NewHandler(&buf, tt.format, slog.LevelInfo)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("NewHandler() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			logger := slog.New(handler).With("user", "saul")
+			logger.InfoContext(tt.ctx, "hello")
+			logger.DebugContext(tt.ctx, "ignored")
+			if got := buf.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("NewHandler() logged %q, want it to contain %q", got, tt.want)
+			}
+			if strings.Contains(buf.String(), "ignored") {
+				t.Errorf("NewHandler() logged a record below its level: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "UUID", id: "0b9e6c4e-8f4a-4c36-9a57-4d7f3c2f8a10", want: true},
+		{name: "Trace-style ID", id: "req_01:abc.DEF", want: true},
+		{name: "Empty", id: "", want: false},
+		{name: "Too long", id: strings.Repeat("a", 129), want: false},
+		{name: "Longest allowed", id: strings.Repeat("a", 128), want: true},
+		{name: "Spaces", id: "abc 123", want: false},
+		{name: "Newline", id: "abc\nlevel=ERROR", want: false},
+		{name: "Quote", id: `abc"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidRequestID(tt.id); got != tt.want {
+				t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserID(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		set  string
+		want string
+	}{
+		{name: "Recorded", ctx: WithRequestUser(context.Background()), set: "user-1", want: "user-1"},
+		{name: "Not recorded yet", ctx: WithRequestUser(context.Background()), want: ""},
+		{name: "Without a holder", ctx: context.Background(), set: "user-1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set != "" {
+				// The ID is set on a derived context, as a handler would, and read from the original.
+				SetUserID(WithRequestID(tt.ctx, "abc-123"), tt.set)
+			}
+			if got := UserID(tt.ctx); got != tt.want {
+				t.Errorf("UserID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
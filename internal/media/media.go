@@ -0,0 +1,272 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder.
+)
+
+const (
+	// MaxPixels bounds the dimensions of an accepted image, protecting against decompression bombs.
+	MaxPixels = 40_000_000
+	// MaxGIFFrames bounds the number of frames of an animated GIF.
+	MaxGIFFrames = 500
+	// MaxGIFPixels bounds the pixels of all the frames of an animated GIF together, which are all decoded
+	// in memory at once.
+	MaxGIFPixels = MaxPixels
+	// ThumbnailSize is the maximum width and height of a thumbnail.
+	ThumbnailSize = 400
+	// jpegQuality is the quality used when re-encoding JPEG images.
+	jpegQuality = 85
+)
+
+var (
+	// ErrUnsupportedType is returned when the uploaded bytes aren't a supported image format.
+	ErrUnsupportedType = errors.New("unsupported image type, must be JPEG, PNG, GIF or WebP")
+	// ErrTooManyPixels is returned when the image dimensions exceed MaxPixels, or the frames of a GIF
+	// MaxGIFPixels.
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+	// ErrTooManyFrames is returned when an animated GIF has more than MaxGIFFrames frames.
+	ErrTooManyFrames = errors.New("animated GIF has too many frames")
+)
+
+// Image is a processed upload. Data holds the re-encoded image, which no longer carries
+// the original metadata (EXIF, comments, ...), and Thumbnail a downscaled copy of it.
+type Image struct {
+	ContentType          string
+	Extension            string
+	Width                int
+	Height               int
+	Data                 []byte
+	ThumbnailContentType string
+	ThumbnailExtension   string
+	Thumbnail            []byte
+}
+
+// Process validates an uploaded image by sniffing its bytes rather than trusting the declared
+// content type, re-encodes it to strip metadata, and generates its thumbnail.
+// JPEG and GIF images keep their format, PNG and WebP images are stored as PNG.
+func Process(data []byte) (Image, error) {
+	contentType, cfg, err := sniff(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	out := Image{Width: cfg.Width, Height: cfg.Height}
+	var first image.Image
+	switch contentType {
+	case "image/gif":
+		err = checkGIFFrames(data)
+		if err != nil {
+			return Image{}, err
+		}
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, ErrUnsupportedType
+		}
+		var buf bytes.Buffer
+		err = gif.EncodeAll(&buf, anim)
+		if err != nil {
+			return Image{}, err
+		}
+		out.ContentType, out.Extension, out.Data = "image/gif", ".gif", buf.Bytes()
+		first = anim.Image[0]
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, ErrUnsupportedType
+		}
+		out.ContentType, out.Extension, out.Data, err = encode(img, contentType == "image/jpeg")
+		if err != nil {
+			return Image{}, err
+		}
+		first = img
+	}
+
+	thumb := Fit(first, ThumbnailSize, ThumbnailSize)
+	out.ThumbnailContentType, out.ThumbnailExtension, out.Thumbnail, err = encode(thumb, contentType == "image/jpeg")
+	if err != nil {
+		return Image{}, err
+	}
+	return out, nil
+}
+
+// Size is a fixed size an image is resized to.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Variant is an image resized to one of the requested sizes.
+type Variant struct {
+	Size
+	ContentType string
+	Extension   string
+	Data        []byte
+}
+
+// Resize validates an uploaded image like Process and returns one variant per size, each cropped
+// around its center and scaled to fill the size exactly. Animated GIFs keep only their first frame.
+// JPEG images stay JPEG, every other format is stored as PNG.
+func Resize(data []byte, sizes []Size) ([]Variant, error) {
+	contentType, _, err := sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	variants := make([]Variant, 0, len(sizes))
+	for _, size := range sizes {
+		v := Variant{Size: size}
+		resized := Fill(img, size.Width, size.Height)
+		v.ContentType, v.Extension, v.Data, err = encode(resized, contentType == "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// Fill crops an image around its center to the aspect ratio of width x height,
+// then scales it to exactly that size.
+func Fill(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	src := bounds
+	if bounds.Dx()*height > bounds.Dy()*width {
+		cropWidth := max(1, bounds.Dy()*width/height)
+		src.Min.X += (bounds.Dx() - cropWidth) / 2
+		src.Max.X = src.Min.X + cropWidth
+	} else {
+		cropHeight := max(1, bounds.Dx()*height/width)
+		src.Min.Y += (bounds.Dy() - cropHeight) / 2
+		src.Max.Y = src.Min.Y + cropHeight
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// Fit scales an image down, preserving its aspect ratio, so that it fits within maxWidth x maxHeight.
+// Images that already fit are returned unchanged.
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return img
+	}
+	if width*maxHeight > height*maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	} else {
+		width = max(1, width*maxHeight/height)
+		height = maxHeight
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// sniff detects the type of an uploaded image from its bytes and checks its dimensions
+// without decoding the whole image.
+func sniff(data []byte) (string, image.Config, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return "", image.Config{}, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", image.Config{}, ErrUnsupportedType
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return "", image.Config{}, ErrTooManyPixels
+	}
+	return contentType, cfg, nil
+}
+
+// checkGIFFrames walks the blocks of a GIF without decoding them and fails when the image has more than
+// MaxGIFFrames frames or more than MaxGIFPixels pixels across its frames. A malformed stream is left for
+// the decoder to reject.
+func checkGIFFrames(data []byte) error {
+	// The header and logical screen descriptor take 13 bytes, followed by the global color table.
+	const headerSize = 13
+	if len(data) < headerSize {
+		return nil
+	}
+	i := headerSize
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // Extension: introducer, label and data sub-blocks.
+			i = skipGIFSubBlocks(data, i+2)
+		case 0x2C: // Image descriptor: separator, position, size and flags, then the image data.
+			if i+10 > len(data) {
+				return nil
+			}
+			width := int(data[i+5]) | int(data[i+6])<<8
+			height := int(data[i+7]) | int(data[i+8])<<8
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			frames++
+			pixels += width * height
+			if frames > MaxGIFFrames {
+				return ErrTooManyFrames
+			}
+			if pixels > MaxGIFPixels {
+				return ErrTooManyPixels
+			}
+			// Skip the LZW minimum code size, then the data sub-blocks.
+			i = skipGIFSubBlocks(data, i+1)
+		default: // Trailer, or a malformed stream.
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipGIFSubBlocks returns the index following the data sub-blocks that start at i, which end with an
+// empty block.
+func skipGIFSubBlocks(data []byte, i int) int {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// encode encodes an image as JPEG when asJPEG is set, or as PNG otherwise,
+// and returns its content type and file extension.
+func encode(img image.Image, asJPEG bool) (string, string, []byte, error) {
+	var buf bytes.Buffer
+	if asJPEG {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		return "image/jpeg", ".jpg", buf.Bytes(), err
+	}
+	err := png.Encode(&buf, img)
+	return "image/png", ".png", buf.Bytes(), err
+}
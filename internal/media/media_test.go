@@ -0,0 +1,252 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withEXIF inserts an APP1 EXIF segment right after the JPEG start-of-image marker.
+func withEXIF(t *testing.T, jpg []byte) []byte {
+	t.Helper()
+	payload := []byte("Exif\x00\x00GPS-SECRET-LOCATION")
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+// animatedGIF encodes an animated GIF of the given number of frames.
+func animatedGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	anim := &gif.GIF{}
+	for range frames {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// rawGIF writes the blocks of a GIF whose frames all have the size of its screen, with a single byte of
+// image data each, which is enough for the frames to be counted but not to be decoded.
+func rawGIF(width, height, frames int) []byte {
+	data := []byte("GIF89a")
+	data = append(data, byte(width), byte(width>>8), byte(height), byte(height>>8), 0x80, 0, 0)
+	data = append(data, 0, 0, 0, 255, 255, 255)
+	for range frames {
+		data = append(data, 0x2C, 0, 0, 0, 0, byte(width), byte(width>>8), byte(height), byte(height>>8), 0)
+		data = append(data, 2, 1, 0, 0)
+	}
+	return append(data, 0x3B)
+}
+
+func TestProcess(t *testing.T) {
+	var pngBuf, jpgBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, testImage(800, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpgBuf, testImage(300, 600), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantErr         error
+		wantContentType string
+		wantWidth       int
+		wantHeight      int
+		wantThumbWidth  int
+		wantThumbHeight int
+	}{
+		{
+			name:            "PNG",
+			data:            pngBuf.Bytes(),
+			wantContentType: "image/png",
+			wantWidth:       800,
+			wantHeight:      200,
+			wantThumbWidth:  400,
+			wantThumbHeight: 100,
+		},
+		{
+			name:            "JPEG with EXIF",
+			data:            withEXIF(t, jpgBuf.Bytes()),
+			wantContentType: "image/jpeg",
+			wantWidth:       300,
+			wantHeight:      600,
+			wantThumbWidth:  200,
+			wantThumbHeight: 400,
+		},
+		{
+			name:    "Not an image",
+			data:    []byte("<html><body>hello</body></html>"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Truncated PNG",
+			data:    pngBuf.Bytes()[:20],
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:            "Animated GIF",
+			data:            animatedGIF(t, 120, 60, 3),
+			wantContentType: "image/gif",
+			wantWidth:       120,
+			wantHeight:      60,
+			wantThumbWidth:  120,
+			wantThumbHeight: 60,
+		},
+		{
+			name:    "GIF with too many frames",
+			data:    rawGIF(1, 1, MaxGIFFrames+1),
+			wantErr: ErrTooManyFrames,
+		},
+		{
+			name:    "GIF with too many pixels across its frames",
+			data:    rawGIF(6000, 6000, 2),
+			wantErr: ErrTooManyPixels,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Process(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", got.ContentType, tt.wantContentType)
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", got.Width, got.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if bytes.Contains(got.Data, []byte("GPS-SECRET-LOCATION")) {
+				t.Error("processed image still contains EXIF metadata")
+			}
+			thumb, _, err := image.Decode(bytes.NewReader(got.Thumbnail))
+			if err != nil {
+				t.Fatalf("decoding thumbnail: %v", err)
+			}
+			if b := thumb.Bounds(); b.Dx() != tt.wantThumbWidth || b.Dy() != tt.wantThumbHeight {
+				t.Errorf("thumbnail size = %dx%d, want %dx%d",
+					b.Dx(), b.Dy(), tt.wantThumbWidth, tt.wantThumbHeight)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	var pngBuf, jpgBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, testImage(800, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpgBuf, testImage(30, 60), nil); err != nil {
+		t.Fatal(err)
+	}
+	sizes := []Size{{Name: "large", Width: 400, Height: 400}, {Name: "small", Width: 90, Height: 30}}
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantErr         error
+		wantContentType string
+	}{
+		{
+			name:            "Wide PNG",
+			data:            pngBuf.Bytes(),
+			wantContentType: "image/png",
+		},
+		{
+			name:            "Small JPEG is upscaled",
+			data:            withEXIF(t, jpgBuf.Bytes()),
+			wantContentType: "image/jpeg",
+		},
+		{
+			name:    "Not an image",
+			data:    []byte("<html><body>hello</body></html>"),
+			wantErr: ErrUnsupportedType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resize(tt.data, sizes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resize() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(got) != len(sizes) {
+				t.Fatalf("got %d variants, want %d", len(got), len(sizes))
+			}
+			for i, v := range got {
+				if v.Size != sizes[i] {
+					t.Errorf("variant %d size = %+v, want %+v", i, v.Size, sizes[i])
+				}
+				if v.ContentType != tt.wantContentType {
+					t.Errorf("variant %d ContentType = %q, want %q", i, v.ContentType, tt.wantContentType)
+				}
+				if bytes.Contains(v.Data, []byte("GPS-SECRET-LOCATION")) {
+					t.Errorf("variant %d still contains EXIF metadata", i)
+				}
+				img, _, err := image.Decode(bytes.NewReader(v.Data))
+				if err != nil {
+					t.Fatalf("decoding variant %d: %v", i, err)
+				}
+				if b := img.Bounds(); b.Dx() != v.Width || b.Dy() != v.Height {
+					t.Errorf("variant %d is %dx%d, want %dx%d", i, b.Dx(), b.Dy(), v.Width, v.Height)
+				}
+			}
+		})
+	}
+}
+
+func TestFillCropsAroundCenter(t *testing.T) {
+	// A 300x100 image whose left and right thirds are red and middle third is blue.
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for x := range 300 {
+		c := color.RGBA{R: 255, A: 255}
+		if x >= 100 && x < 200 {
+			c = color.RGBA{B: 255, A: 255}
+		}
+		for y := range 100 {
+			img.Set(x, y, c)
+		}
+	}
+
+	got := Fill(img, 50, 50)
+	if b := got.Bounds(); b.Dx() != 50 || b.Dy() != 50 {
+		t.Fatalf("size = %dx%d, want 50x50", b.Dx(), b.Dy())
+	}
+	for _, p := range []image.Point{{0, 0}, {25, 25}, {49, 49}} {
+		r, _, b, _ := got.At(p.X, p.Y).RGBA()
+		if r != 0 || b == 0 {
+			t.Errorf("pixel %v is not blue, the crop is off center", p)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package profanity
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+)
+
+// Mode tells what happens to a chirp containing a banned term.
+type Mode string
+
+const (
+	// ModeMask replaces the term with asterisks.
+	ModeMask Mode = "mask"
+	// ModeReject refuses the chirp.
+	ModeReject Mode = "reject"
+	// ModeFlag accepts the chirp as is and flags it for review.
+	ModeFlag Mode = "flag"
+)
+
+// mask replaces masked terms in a chirp body.
+const mask = "****"
+
+// Valid reports whether m is a known mode.
+func (m Mode) Valid() bool {
+	return m == ModeMask || m == ModeReject || m == ModeFlag
+}
+
+// Term is a banned term and the action to take when it is found.
+type Term struct {
+	Text string
+	Mode Mode
+}
+
+// Result is the outcome of checking a chirp body against a Filter.
+type Result struct {
+	// Cleaned is the body with masked terms replaced by asterisks.
+	Cleaned string
+	// Rejected is true when the body contains a term in reject mode.
+	Rejected bool
+	// Flagged lists the terms in flag mode found in the body, without duplicates.
+	Flagged []string
+}
+
+// Filter matches chirp bodies against a list of banned terms.
+// A Filter is immutable and safe for concurrent use.
+type Filter struct {
+	terms map[string]Term
+}
+
+// leetVariants maps look-alike digits and symbols to letters. Ambiguous characters
+// are mapped differently by each variant, and a word matches if any variant matches.
+var leetVariants = []map[rune]rune{
+	{
+		'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+		'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't',
+	},
+	{
+		'0': 'o', '1': 'l', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+		'@': 'a', '$': 's', '!': 'l', '|': 'l', '+': 't',
+	},
+}
+
+// New compiles a Filter from a list of terms.
+// Terms are matched on whole words, ignoring case and leetspeak substitutions.
+func New(terms []Term) *Filter {
+	f := &Filter{terms: make(map[string]Term, len(terms))}
+	for _, term := range terms {
+		for _, folded := range foldVariants(term.Text) {
+			f.terms[folded] = term
+		}
+	}
+	return f
+}
+
+// Normalize returns the canonical form under which a term is stored: case folded and trimmed.
+// It returns an empty string if the term isn't a single word.
+func Normalize(term string) string {
+	folded := cases.Fold().String(strings.TrimSpace(term))
+	if folded == "" || strings.IndexFunc(folded, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return ""
+	}
+	return folded
+}
+
+// Check matches a chirp body against the filter's terms.
+// Words are delimited by Unicode word boundaries: any rune that isn't a letter, mark, digit
+// or leetspeak symbol ends a word, so punctuation next to a term doesn't hide it.
+func (f *Filter) Check(body string) Result {
+	var result Result
+	var cleaned strings.Builder
+	seen := map[string]bool{}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			cleaned.WriteRune(runes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+
+		start, stop, term, ok := f.match(runes[i:end])
+		switch {
+		case !ok:
+			cleaned.WriteString(string(runes[i:end]))
+		case term.Mode == ModeMask:
+			cleaned.WriteString(string(runes[i : i+start]))
+			cleaned.WriteString(mask)
+			cleaned.WriteString(string(runes[i+stop : end]))
+		default:
+			if term.Mode == ModeReject {
+				result.Rejected = true
+			}
+			if term.Mode == ModeFlag && !seen[term.Text] {
+				seen[term.Text] = true
+				result.Flagged = append(result.Flagged, term.Text)
+			}
+			cleaned.WriteString(string(runes[i:end]))
+		}
+		i = end
+	}
+
+	result.Cleaned = cleaned.String()
+	return result
+}
+
+// match looks a word up in the filter. The whole word is tried first, then the word stripped of
+// leading and trailing symbols, so that "$harbert" and "sharbert!" both match "sharbert".
+// It returns the bounds of the matched part of the word.
+func (f *Filter) match(word []rune) (int, int, Term, bool) {
+	if len(f.terms) == 0 {
+		return 0, 0, Term{}, false
+	}
+	start, stop := 0, len(word)
+	for start < stop && isSymbol(word[start]) {
+		start++
+	}
+	for stop > start && isSymbol(word[stop-1]) {
+		stop--
+	}
+
+	for _, bounds := range [][2]int{{0, len(word)}, {start, stop}} {
+		if bounds[0] >= bounds[1] {
+			continue
+		}
+		for _, folded := range foldVariants(string(word[bounds[0]:bounds[1]])) {
+			if term, ok := f.terms[folded]; ok {
+				return bounds[0], bounds[1], term, true
+			}
+		}
+	}
+	return 0, 0, Term{}, false
+}
+
+// foldVariants case folds a word and replaces leetspeak characters, once per leet variant.
+func foldVariants(word string) []string {
+	folded := cases.Fold().String(word)
+	variants := make([]string, 0, len(leetVariants))
+	for _, leet := range leetVariants {
+		variant := strings.Map(func(r rune) rune {
+			if l, ok := leet[r]; ok {
+				return l
+			}
+			return r
+		}, folded)
+		if len(variants) == 0 || variants[len(variants)-1] != variant {
+			variants = append(variants, variant)
+		}
+	}
+	return variants
+}
+
+// isWordRune reports whether r can be part of a word, leetspeak symbols included.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || isSymbol(r)
+}
+
+// isSymbol reports whether r is a leetspeak symbol standing for a letter.
+func isSymbol(r rune) bool {
+	_, ok := leetVariants[0][r]
+	return ok && !unicode.IsDigit(r)
+}
@@ -0,0 +1,102 @@
+package profanity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	filter := New([]Term{
+		{Text: "kerfuffle", Mode: ModeMask},
+		{Text: "sharbert", Mode: ModeMask},
+		{Text: "fornax", Mode: ModeReject},
+		{Text: "grumble", Mode: ModeFlag},
+	})
+
+	tests := []struct {
+		name         string
+		body         string
+		wantCleaned  string
+		wantRejected bool
+		wantFlagged  []string
+	}{
+		{
+			name:        "Clean body",
+			body:        "I had something interesting for breakfast",
+			wantCleaned: "I had something interesting for breakfast",
+		},
+		{
+			name:        "Masks regardless of case",
+			body:        "This is a Kerfuffle opinion",
+			wantCleaned: "This is a **** opinion",
+		},
+		{
+			name:        "Masks next to punctuation",
+			body:        "Kerfuffle! What a sharbert, really.",
+			wantCleaned: "****! What a ****, really.",
+		},
+		{
+			name:        "Masks leetspeak",
+			body:        "k3rfuffl3 and $harb3rt",
+			wantCleaned: "**** and ****",
+		},
+		{
+			name:        "Doesn't mask inside longer words",
+			body:        "kerfuffles are sharbertish",
+			wantCleaned: "kerfuffles are sharbertish",
+		},
+		{
+			name:         "Rejects",
+			body:         "by the F0RNAX",
+			wantCleaned:  "by the F0RNAX",
+			wantRejected: true,
+		},
+		{
+			name:        "Flags once",
+			body:        "grumble grumble",
+			wantCleaned: "grumble grumble",
+			wantFlagged: []string{"grumble"},
+		},
+		{
+			name:        "Unicode words",
+			body:        "«kerfuffle» 日本",
+			wantCleaned: "«****» 日本",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.Check(tt.body)
+			if got.Cleaned != tt.wantCleaned {
+				t.Errorf("Cleaned = %q, want %q", got.Cleaned, tt.wantCleaned)
+			}
+			if got.Rejected != tt.wantRejected {
+				t.Errorf("Rejected = %v, want %v", got.Rejected, tt.wantRejected)
+			}
+			if !reflect.DeepEqual(got.Flagged, tt.wantFlagged) {
+				t.Errorf("Flagged = %v, want %v", got.Flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want string
+	}{
+		{name: "Folds case", term: "  KerFuffle ", want: "kerfuffle"},
+		{name: "Folds sharp s", term: "Straße", want: "strasse"},
+		{name: "Rejects spaces", term: "two words", want: ""},
+		{name: "Rejects empty", term: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.term); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
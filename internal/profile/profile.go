@@ -0,0 +1,101 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alnah/go-httpserver/internal/textnorm"
+)
+
+const (
+	// MinHandleLength is the minimum number of characters of a handle.
+	MinHandleLength = 3
+	// MaxHandleLength is the maximum number of characters of a handle.
+	MaxHandleLength = 30
+	// MaxDisplayNameLength is the maximum number of characters of a display name.
+	MaxDisplayNameLength = 50
+	// MaxBioLength is the maximum number of characters of a bio.
+	MaxBioLength = 160
+	// MaxLocationLength is the maximum number of characters of a location.
+	MaxLocationLength = 30
+	// MaxWebsiteLength is the maximum number of bytes of a website URL.
+	MaxWebsiteLength = 100
+)
+
+// reservedHandles can't be taken because they name routes or could impersonate the service.
+var reservedHandles = map[string]bool{
+	"me":      true,
+	"admin":   true,
+	"chirpy":  true,
+	"support": true,
+}
+
+var (
+	// ErrInvalidHandle is returned for handles with characters other than ASCII letters, digits and '_'.
+	ErrInvalidHandle = errors.New("handle may only contain letters, digits and underscores")
+	// ErrReservedHandle is returned for handles that can't be taken.
+	ErrReservedHandle = errors.New("handle is reserved")
+	// ErrInvalidWebsite is returned for websites that aren't HTTP or HTTPS URLs.
+	ErrInvalidWebsite = errors.New("website must be an http or https URL")
+)
+
+// ValidateHandle checks that a handle is 3 to 30 ASCII letters, digits or underscores, is not only made
+// of digits, and is not reserved. Handles are compared case-insensitively.
+func ValidateHandle(handle string) error {
+	if len(handle) < MinHandleLength || len(handle) > MaxHandleLength {
+		return fmt.Errorf("handle must be %d to %d characters long", MinHandleLength, MaxHandleLength)
+	}
+	onlyDigits := true
+	for _, r := range handle {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			onlyDigits = false
+		default:
+			return ErrInvalidHandle
+		}
+	}
+	if onlyDigits {
+		return ErrInvalidHandle
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return ErrReservedHandle
+	}
+	return nil
+}
+
+// CleanText normalizes a free-text profile field and checks its length. Line breaks are only kept
+// when multiline is set; otherwise they are replaced with spaces. Surrounding whitespace is trimmed.
+func CleanText(field, s string, maxLength int, multiline bool) (string, error) {
+	s = textnorm.Normalize(s)
+	if !multiline {
+		s = strings.NewReplacer("\n", " ", "\t", " ").Replace(s)
+	}
+	s = strings.TrimSpace(s)
+	if textnorm.Length(s) > maxLength {
+		return "", fmt.Errorf("%s must be at most %d characters long", field, maxLength)
+	}
+	return s, nil
+}
+
+// NormalizeWebsite validates a website URL, adding the "https://" scheme when none is given.
+// An empty string clears the website and is returned as is.
+func NormalizeWebsite(website string) (string, error) {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return "", nil
+	}
+	if !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+	if len(website) > MaxWebsiteLength {
+		return "", fmt.Errorf("website must be at most %d characters long", MaxWebsiteLength)
+	}
+	u, err := url.Parse(website)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return "", ErrInvalidWebsite
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,74 @@
+// Package quota evaluates rolling-window limits on the number of events, such as chirps posted by a user.
+package quota
+
+import (
+	"time"
+)
+
+// Window limits the number of events in any rolling period of the given length.
+type Window struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Status describes the most restrictive window for a new event.
+type Status struct {
+	// Allowed reports whether a new event fits in every window.
+	Allowed bool
+	// Limit is the limit of the most restrictive window.
+	Limit int
+	// Remaining is the number of events still allowed in that window.
+	Remaining int
+	// Reset is when that window frees up a slot for a new event.
+	Reset time.Time
+}
+
+// Check evaluates past events, sorted oldest first, against windows at time now.
+// When a new event is allowed, the status describes the window with the fewest remaining events;
+// otherwise it describes the full window that frees up last, so Reset tells when to retry.
+func Check(now time.Time, events []time.Time, windows []Window) Status {
+	var best Status
+	for i, w := range windows {
+		s := checkWindow(now, events, w)
+		switch {
+		case i == 0:
+			best = s
+		case best.Allowed && !s.Allowed:
+			best = s
+		case best.Allowed == s.Allowed && !s.Allowed && s.Reset.After(best.Reset):
+			best = s
+		case best.Allowed == s.Allowed && s.Allowed && s.Remaining < best.Remaining:
+			best = s
+		}
+	}
+	return best
+}
+
+// checkWindow evaluates a single window.
+func checkWindow(now time.Time, events []time.Time, w Window) Status {
+	since := now.Add(-w.Period)
+	first := len(events)
+	for i, t := range events {
+		if t.After(since) {
+			first = i
+			break
+		}
+	}
+	inWindow := events[first:]
+	count := len(inWindow)
+
+	s := Status{
+		Allowed:   count < w.Limit,
+		Limit:     w.Limit,
+		Remaining: max(0, w.Limit-count),
+		Reset:     now.Add(w.Period),
+	}
+	switch {
+	case count >= w.Limit && w.Limit > 0:
+		// A slot frees up once enough events leave the window to drop below the limit.
+		s.Reset = inWindow[count-w.Limit].Add(w.Period)
+	case count > 0:
+		s.Reset = inWindow[0].Add(w.Period)
+	}
+	return s
+}
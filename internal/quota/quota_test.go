@@ -0,0 +1,62 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	windows := []Window{{Limit: 3, Period: time.Hour}, {Limit: 5, Period: 24 * time.Hour}}
+
+	tests := []struct {
+		name   string
+		events []time.Time
+		want   Status
+	}{
+		{
+			name:   "No events",
+			events: nil,
+			want:   Status{Allowed: true, Limit: 3, Remaining: 3, Reset: now.Add(time.Hour)},
+		},
+		{
+			name:   "Hourly window is the most restrictive",
+			events: []time.Time{ago(30 * time.Minute), ago(10 * time.Minute)},
+			want:   Status{Allowed: true, Limit: 3, Remaining: 1, Reset: ago(30 * time.Minute).Add(time.Hour)},
+		},
+		{
+			name:   "Old events leave the hourly window",
+			events: []time.Time{ago(5 * time.Hour), ago(4 * time.Hour), ago(3 * time.Hour), ago(time.Minute)},
+			want:   Status{Allowed: true, Limit: 5, Remaining: 1, Reset: ago(5 * time.Hour).Add(24 * time.Hour)},
+		},
+		{
+			name:   "Hourly limit reached",
+			events: []time.Time{ago(50 * time.Minute), ago(40 * time.Minute), ago(time.Minute)},
+			want:   Status{Allowed: false, Limit: 3, Remaining: 0, Reset: ago(50 * time.Minute).Add(time.Hour)},
+		},
+		{
+			name: "Daily limit reached",
+			events: []time.Time{
+				ago(20 * time.Hour), ago(19 * time.Hour), ago(18 * time.Hour), ago(17 * time.Hour), ago(time.Minute),
+			},
+			want: Status{Allowed: false, Limit: 5, Remaining: 0, Reset: ago(20 * time.Hour).Add(24 * time.Hour)},
+		},
+		{
+			name: "Both limits reached, the later reset wins",
+			events: []time.Time{
+				ago(20 * time.Hour), ago(19 * time.Hour), ago(3 * time.Minute), ago(2 * time.Minute), ago(time.Minute),
+			},
+			want: Status{Allowed: false, Limit: 5, Remaining: 0, Reset: ago(20 * time.Hour).Add(24 * time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(now, tt.events, windows)
+			if got != tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package spam scores chirps with pluggable heuristics to decide whether to accept, hold or reject them.
+package spam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Decision is what happens to a chirp given its spam score.
+type Decision string
+
+const (
+	// Accept publishes the chirp.
+	Accept Decision = "accept"
+	// Hold keeps the chirp pending until a moderator reviews it.
+	Hold Decision = "hold"
+	// Reject refuses the chirp.
+	Reject Decision = "reject"
+)
+
+const (
+	// DefaultHoldScore is the score from which chirps are held for moderation.
+	DefaultHoldScore = 0.6
+	// DefaultRejectScore is the score from which chirps are rejected.
+	DefaultRejectScore = 1.0
+	// duplicateSimilarity is the similarity from which a chirp is a near-duplicate of another.
+	duplicateSimilarity = 0.8
+)
+
+// urlPattern matches the links of a chirp body.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// Input is what the checks know about a chirp being posted.
+type Input struct {
+	// Body is the cleaned body of the chirp.
+	Body string
+	// RecentBodies are the bodies of the author's recent chirps.
+	RecentBodies []string
+	// Mentions is the number of users the chirp mentions.
+	Mentions int
+	// AccountAge is how long ago the author signed up.
+	AccountAge time.Duration
+}
+
+// Signal is the outcome of a single check. A zero Score means the check found nothing suspicious.
+type Signal struct {
+	Score  float64
+	Reason string
+}
+
+// Check is a spam heuristic.
+type Check interface {
+	Check(in Input) Signal
+}
+
+// CheckFunc adapts a function to the Check interface.
+type CheckFunc func(in Input) Signal
+
+// Check calls f.
+func (f CheckFunc) Check(in Input) Signal {
+	return f(in)
+}
+
+// Verdict is the outcome of scoring a chirp.
+type Verdict struct {
+	// Score is the sum of the scores of the checks.
+	Score float64
+	// Decision is derived from Score and the scorer's thresholds.
+	Decision Decision
+	// Reasons explains the checks that contributed to Score.
+	Reasons []string
+}
+
+// Scorer runs checks against chirps and sums their scores.
+type Scorer struct {
+	checks      []Check
+	holdScore   float64
+	rejectScore float64
+}
+
+// NewScorer creates a scorer running the given checks, holding chirps scoring holdScore or more
+// and rejecting those scoring rejectScore or more.
+func NewScorer(holdScore, rejectScore float64, checks ...Check) *Scorer {
+	return &Scorer{checks: checks, holdScore: holdScore, rejectScore: rejectScore}
+}
+
+// NewDefaultScorer creates a scorer running every built-in check with the default thresholds.
+func NewDefaultScorer() *Scorer {
+	return NewScorer(DefaultHoldScore, DefaultRejectScore,
+		CheckFunc(CheckDuplicates),
+		CheckFunc(CheckLinks),
+		CheckFunc(CheckMentions),
+		CheckFunc(CheckAccountAge),
+	)
+}
+
+// Score runs every check against a chirp.
+func (s *Scorer) Score(in Input) Verdict {
+	v := Verdict{Decision: Accept}
+	for _, c := range s.checks {
+		signal := c.Check(in)
+		if signal.Score <= 0 {
+			continue
+		}
+		v.Score += signal.Score
+		v.Reasons = append(v.Reasons, signal.Reason)
+	}
+	switch {
+	case v.Score >= s.rejectScore:
+		v.Decision = Reject
+	case v.Score >= s.holdScore:
+		v.Decision = Hold
+	}
+	return v
+}
+
+// CheckDuplicates flags chirps nearly identical to recent chirps of their author.
+// Each near-duplicate raises the score, so a chirp posted over and over ends up rejected.
+func CheckDuplicates(in Input) Signal {
+	words := wordSet(in.Body)
+	if len(words) == 0 {
+		return Signal{}
+	}
+	duplicates := 0
+	for _, recent := range in.RecentBodies {
+		if similarity(words, wordSet(recent)) >= duplicateSimilarity {
+			duplicates++
+		}
+	}
+	if duplicates == 0 {
+		return Signal{}
+	}
+	return Signal{
+		Score:  0.4 + 0.3*float64(duplicates-1),
+		Reason: fmt.Sprintf("near-duplicate of %d recent chirps", duplicates),
+	}
+}
+
+// CheckLinks flags chirps made mostly of links.
+func CheckLinks(in Input) Signal {
+	links := len(urlPattern.FindAllString(in.Body, -1))
+	if links == 0 {
+		return Signal{}
+	}
+	words := len(strings.Fields(urlPattern.ReplaceAllString(in.Body, " ")))
+	switch {
+	case links >= 3:
+		return Signal{Score: 0.6, Reason: fmt.Sprintf("%d links", links)}
+	case links > words:
+		return Signal{Score: 0.3, Reason: "mostly links"}
+	}
+	return Signal{}
+}
+
+// CheckMentions flags chirps mentioning many users at once.
+func CheckMentions(in Input) Signal {
+	switch {
+	case in.Mentions >= 10:
+		return Signal{Score: 0.8, Reason: fmt.Sprintf("mentions %d users", in.Mentions)}
+	case in.Mentions >= 5:
+		return Signal{Score: 0.4, Reason: fmt.Sprintf("mentions %d users", in.Mentions)}
+	}
+	return Signal{}
+}
+
+// CheckAccountAge makes chirps from new accounts more suspicious. It never holds a chirp on its own.
+func CheckAccountAge(in Input) Signal {
+	switch {
+	case in.AccountAge < time.Hour:
+		return Signal{Score: 0.3, Reason: "account is less than an hour old"}
+	case in.AccountAge < 24*time.Hour:
+		return Signal{Score: 0.1, Reason: "account is less than a day old"}
+	}
+	return Signal{}
+}
+
+// wordSet returns the set of lowercased words of a body, ignoring punctuation, links
+// and the entities' sigils, so that changing a link or adding punctuation isn't enough to look different.
+func wordSet(body string) map[string]bool {
+	body = urlPattern.ReplaceAllString(strings.ToLower(body), " ")
+	words := strings.FieldsFunc(body, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// similarity returns the Jaccard index of two word sets.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for w := range a {
+		if b[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,106 @@
+package spam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScorer(t *testing.T) {
+	const old = 30 * 24 * time.Hour
+	tests := []struct {
+		name        string
+		input       Input
+		want        Decision
+		wantReasons int
+	}{
+		{
+			name:  "Regular chirp",
+			input: Input{Body: "Lunch at the new place downtown", RecentBodies: []string{"Good morning!"}, AccountAge: old},
+			want:  Accept,
+		},
+		{
+			name:        "One link is fine",
+			input:       Input{Body: "My write-up: https://example.com/post", AccountAge: old},
+			want:        Accept,
+			wantReasons: 0,
+		},
+		{
+			name:        "New account alone",
+			input:       Input{Body: "Hello world", AccountAge: time.Minute},
+			want:        Accept,
+			wantReasons: 1,
+		},
+		{
+			name: "Duplicate with a different link",
+			input: Input{
+				Body:         "Buy cheap followers now! https://spam.example/b",
+				RecentBodies: []string{"buy cheap followers NOW https://spam.example/a", "Good morning"},
+				AccountAge:   old,
+			},
+			want:        Accept,
+			wantReasons: 1,
+		},
+		{
+			name: "Repeated duplicate is held",
+			input: Input{
+				Body:         "Buy cheap followers now!",
+				RecentBodies: []string{"Buy cheap followers now!", "buy cheap followers now"},
+				AccountAge:   old,
+			},
+			want:        Hold,
+			wantReasons: 1,
+		},
+		{
+			name: "Flooded duplicate is rejected",
+			input: Input{
+				Body:         "Buy cheap followers now!",
+				RecentBodies: []string{"Buy cheap followers now!", "Buy cheap followers now!", "Buy cheap followers now"},
+				AccountAge:   old,
+			},
+			want:        Reject,
+			wantReasons: 1,
+		},
+		{
+			name:        "Link dump is held",
+			input:       Input{Body: "https://a.example www.b.example http://c.example", AccountAge: old},
+			want:        Hold,
+			wantReasons: 1,
+		},
+		{
+			name:        "Mention flood from a new account is rejected",
+			input:       Input{Body: "hey", Mentions: 10, AccountAge: 10 * time.Minute},
+			want:        Reject,
+			wantReasons: 2,
+		},
+	}
+
+	scorer := NewDefaultScorer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scorer.Score(tt.input)
+			if got.Decision != tt.want {
+				t.Errorf("Decision = %q (score %.2f, reasons %v), want %q", got.Decision, got.Score, got.Reasons, tt.want)
+			}
+			if len(got.Reasons) != tt.wantReasons {
+				t.Errorf("Reasons = %v, want %d reasons", got.Reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestScorerCustomCheck(t *testing.T) {
+	banned := CheckFunc(func(in Input) Signal {
+		if in.Body == "forbidden" {
+			return Signal{Score: 5, Reason: "forbidden"}
+		}
+		return Signal{}
+	})
+	scorer := NewScorer(1, 2, banned)
+
+	if got := scorer.Score(Input{Body: "forbidden"}); got.Decision != Reject {
+		t.Errorf("Decision = %q, want %q", got.Decision, Reject)
+	}
+	if got := scorer.Score(Input{Body: "allowed"}); got.Decision != Accept || got.Reasons != nil {
+		t.Errorf("got %+v, want an accepted chirp without reasons", got)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorePutDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	err = store.Put(ctx, "media/abc.png", strings.NewReader("content"), "image/png")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "media", "abc.png"))
+	if err != nil || string(got) != "content" {
+		t.Fatalf("stored content = %q, %v, want %q", got, err, "content")
+	}
+	if url := store.URL("media/abc.png"); url != "/media/media/abc.png" {
+		t.Errorf("URL() = %q, want %q", url, "/media/media/abc.png")
+	}
+
+	err = store.Delete(ctx, "media/abc.png")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "media", "abc.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still exists after Delete(), stat error = %v", err)
+	}
+	if err = store.Delete(ctx, "media/abc.png"); err != nil {
+		t.Errorf("Delete() of a missing object error = %v, want nil", err)
+	}
+}
+
+func TestLocalStoreInvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "Empty key", key: ""},
+		{name: "Absolute key", key: "/etc/passwd"},
+		{name: "Parent directory", key: "../secret"},
+		{name: "Unclean key", key: "media/../../secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.Put(context.Background(), tt.key, strings.NewReader("x"), "text/plain")
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put() error = %v, want %v", err, ErrInvalidKey)
+			}
+		})
+	}
+}
+
+func TestLocalStoreServeHTTP(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	err = store.Put(context.Background(), "media/abc.txt", strings.NewReader("hello"), "text/plain")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	handler := http.StripPrefix("/media/", store)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Existing object", path: "/media/media/abc.txt", wantStatus: http.StatusOK},
+		{name: "Missing object", path: "/media/media/missing.txt", wantStatus: http.StatusNotFound},
+		{name: "Directory", path: "/media/media", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
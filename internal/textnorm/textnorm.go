@@ -0,0 +1,27 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns the NFC form of s without its control characters.
+// Line feeds and tabs are kept.
+func Normalize(s string) string {
+	stripped := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	return norm.NFC.String(stripped)
+}
+
+// Length returns the number of user-perceived characters (grapheme clusters) in s,
+// so that an emoji sequence or a letter with combining accents counts as one.
+func Length(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
@@ -0,0 +1,48 @@
+package textnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "ASCII unchanged", input: "Hello Chirpy", want: "Hello Chirpy"},
+		{name: "Composes accents", input: "cafe\u0301", want: "caf\u00e9"},
+		{name: "Strips control characters", input: "a\x00b\x1bc\u0085d\re", want: "abcde"},
+		{name: "Keeps line feeds and tabs", input: "a\nb\tc", want: "a\nb\tc"},
+		{name: "Keeps zero width joiners", input: "👩‍💻", want: "👩‍💻"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "ASCII", input: "Hello", want: 5},
+		{name: "Japanese", input: "こんにちは世界", want: 7},
+		{name: "Combining accent", input: "cafe\u0301", want: 4},
+		{name: "Emoji ZWJ sequence", input: "👩‍💻!", want: 2},
+		{name: "Flag", input: "🇫🇷", want: 1},
+		{name: "Empty", input: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.input); got != tt.want {
+				t.Errorf("Length() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
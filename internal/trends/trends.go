@@ -0,0 +1,98 @@
+// Package trends ranks hashtags by how fast their use is growing.
+package trends
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Window is the sliding window whose usage is compared with the baseline.
+	Window = 6 * time.Hour
+	// Baseline is the period before the window giving a hashtag's usual usage.
+	Baseline = 7 * 24 * time.Hour
+	// MinUses is the number of chirps a hashtag needs in the window to trend.
+	MinUses = 3
+	// MinAuthors is the number of distinct users who must have used a hashtag in the window,
+	// so that a single user can't make a hashtag trend.
+	MinAuthors = 2
+	// smoothing is added to the expected usage so that brand new hashtags don't get infinite scores.
+	smoothing = 2.0
+)
+
+// ErrInvalidLanguage is returned when a language isn't a two-letter ISO 639-1 code.
+var ErrInvalidLanguage = errors.New("invalid language, must be a two-letter ISO 639-1 code")
+
+// Usage is the number of chirps using a hashtag in the window and in the baseline before it.
+type Usage struct {
+	Tag      string
+	Current  int64
+	Previous int64
+	Authors  int64
+}
+
+// Trend is a ranked hashtag.
+type Trend struct {
+	Tag     string
+	Score   float64
+	Uses    int64
+	Authors int64
+}
+
+// Score measures how much a hashtag's usage in the window exceeds what its baseline predicts,
+// in standard deviations of a Poisson process: hashtags used far more often than usual score
+// higher than hashtags that are merely popular.
+func Score(u Usage) float64 {
+	expected := float64(u.Previous) * float64(Window) / float64(Baseline)
+	return (float64(u.Current) - expected) / math.Sqrt(expected+smoothing)
+}
+
+// Rank returns at most limit trending hashtags, highest score first. Hashtags used fewer than
+// MinUses times or by fewer than MinAuthors users in the window, or whose usage isn't growing, are left out.
+func Rank(usages []Usage, limit int) []Trend {
+	trends := make([]Trend, 0, len(usages))
+	for _, u := range usages {
+		if u.Current < MinUses || u.Authors < MinAuthors {
+			continue
+		}
+		score := Score(u)
+		if score <= 0 {
+			continue
+		}
+		trends = append(trends, Trend{Tag: u.Tag, Score: score, Uses: u.Current, Authors: u.Authors})
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		a, b := trends[i], trends[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Uses != b.Uses {
+			return a.Uses > b.Uses
+		}
+		return a.Tag < b.Tag
+	})
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return trends
+}
+
+// NormalizeLanguage validates the language of a chirp and returns it lowercased. Region subtags
+// are dropped, so "en-US" becomes "en". An empty language stays empty and means unknown.
+func NormalizeLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return "", nil
+	}
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	if len(language) != 2 || language[0] < 'a' || language[0] > 'z' || language[1] < 'a' || language[1] > 'z' {
+		return "", ErrInvalidLanguage
+	}
+	return language, nil
+}
@@ -0,0 +1,102 @@
+package trends
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Usage
+	}{
+		{
+			name: "Growth beats raw counts",
+			a:    Usage{Current: 20, Previous: 0},
+			b:    Usage{Current: 100, Previous: 2800},
+		},
+		{
+			name: "More uses beat fewer with the same baseline",
+			a:    Usage{Current: 30, Previous: 28},
+			b:    Usage{Current: 10, Previous: 28},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Score(tt.a) <= Score(tt.b) {
+				t.Errorf("Score(%+v) = %f, want more than Score(%+v) = %f", tt.a, Score(tt.a), tt.b, Score(tt.b))
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	usages := []Usage{
+		{Tag: "steady", Current: 100, Previous: 2800, Authors: 50},
+		{Tag: "rising", Current: 20, Previous: 0, Authors: 12},
+		{Tag: "solo", Current: 40, Previous: 0, Authors: 1},
+		{Tag: "rare", Current: 2, Previous: 0, Authors: 2},
+		{Tag: "growing", Current: 40, Previous: 280, Authors: 8},
+		{Tag: "alsorising", Current: 20, Previous: 0, Authors: 3},
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "All trends",
+			limit: 10,
+			want:  []string{"alsorising", "rising", "growing"},
+		},
+		{
+			name:  "Limited",
+			limit: 1,
+			want:  []string{"alsorising"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, trend := range Rank(usages, tt.limit) {
+				got = append(got, trend.Tag)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr error
+	}{
+		{input: "", want: ""},
+		{input: "en", want: "en"},
+		{input: "FR", want: "fr"},
+		{input: "en-US", want: "en"},
+		{input: "pt_BR", want: "pt"},
+		{input: "eng", wantErr: ErrInvalidLanguage},
+		{input: "e1", wantErr: ErrInvalidLanguage},
+		{input: "-US", wantErr: ErrInvalidLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeLanguage(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeLanguage(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
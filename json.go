@@ -2,34 +2,63 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 )
 
-// respondWithError logs the provided error (if any) and sends a JSON error response
-// with the specified HTTP status code and message.
-func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
+// respondWithError logs the provided error (if any) under the ID of the request and sends
+// a JSON error response with the specified HTTP status code and message.
+// 5XX responses are logged as errors, other responses only when they carry an error.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, msg string, err error) {
+	attrs := []any{"status", code}
 	if err != nil {
-		log.Println(err)
+		attrs = append(attrs, "error", err)
 	}
-	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
+	switch {
+	case code > 499:
+		slog.ErrorContext(r.Context(), msg, attrs...)
+	case err != nil:
+		slog.WarnContext(r.Context(), msg, attrs...)
 	}
 	type errorResponse struct {
 		Error string `json:"error"`
 	}
-	respondWithJSON(w, code, errorResponse{
+	respondWithJSON(w, r, code, errorResponse{
 		Error: msg,
 	})
 }
 
+// respondWithDecodeError sends the error response for a request body that couldn't be decoded:
+// 413 when the body exceeds the limit of its route, 500 when the handler passed an invalid target,
+// and 400 otherwise, the body being malformed, truncated or of the wrong types.
+func respondWithDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, "Request body is too large", err)
+		return
+	}
+	var invalidUnmarshalErr *json.InvalidUnmarshalError
+	if errors.As(err, &invalidUnmarshalErr) {
+		respondWithError(w, r, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	respondWithError(w, r, http.StatusBadRequest, "Couldn't decode parameters", err)
+}
+
 // respondWithJSON sends a JSON response with the provided payload and HTTP status code.
 // It sets the Content-Type header to "application/json" and handles marshalling errors.
-func respondWithJSON(w http.ResponseWriter, code int, payload any) {
-	w.Header().Set("Content-Type", "application/json")
+func respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload any) {
+	respondWithJSONAs(w, r, code, "application/json", payload)
+}
+
+// respondWithJSONAs sends a JSON response like respondWithJSON, under a more specific
+// media type such as "application/activity+json".
+func respondWithJSONAs(w http.ResponseWriter, r *http.Request, code int, contentType string, payload any) {
+	w.Header().Set("Content-Type", contentType)
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		slog.ErrorContext(r.Context(), "Couldn't marshal JSON", "error", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/logging"
+	"github.com/alnah/go-httpserver/internal/spam"
+	"github.com/alnah/go-httpserver/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -17,14 +30,36 @@ import (
 type apiConfig struct {
 	// fileserverHits counts the number of times the file server has been accessed.
 	fileserverHits atomic.Int32
+	// draining is set once the server starts shutting down, so that readiness checks fail.
+	draining atomic.Bool
 	// db provides access to database queries.
 	db *database.Queries
+	// dbConn is the underlying connection pool, used to run queries in transactions.
+	dbConn *sql.DB
 	// platform indicates the running environment (e.g. "dev").
 	platform string
 	// jwtSecret is used to sign and validate JWT tokens.
 	jwtSecret string
 	// polkaAPIKey is the API key used for validating Polka webhook requests.
 	polkaAPIKey string
+	// blobs stores uploaded media files.
+	blobs storage.BlobStore
+	// profanity caches the banned terms used to filter chirps.
+	profanity *profanityFilter
+	// spam scores new chirps to accept, hold or reject them.
+	spam *spam.Scorer
+	// events fans out chirp events and notifications to the realtime endpoints.
+	events *eventHub
+	// federation publishes chirps to remote ActivityPub servers.
+	federation *federation
+	// feeds remembers when the Atom and RSS feeds last changed.
+	feeds *feedVersions
+	// publicURL is the base URL the server is reachable at, used to build absolute links.
+	publicURL string
+	// chirpMaxLength is the maximum number of characters of a chirp.
+	chirpMaxLength int
+	// chirpMaxLengthRed is the maximum number of characters of a chirp for Chirpy Red members.
+	chirpMaxLengthRed int
 }
 
 // main initializes the server configuration, connects to the database, sets up HTTP routes,
@@ -34,6 +69,18 @@ func main() {
 	const port = "8088"
 
 	_ = godotenv.Load()
+	logFormat := os.Getenv("LOG_FORMAT")
+	if logFormat == "" {
+		logFormat = "text"
+	}
+	logHandler, err := logging.NewHandler(os.Stderr, logFormat, slog.LevelInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(slog.New(logHandler))
+	// The remaining log calls report fatal configuration errors.
+	slog.SetLogLoggerLevel(slog.LevelError)
+
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
 		log.Fatal("DB_URL must be set")
@@ -51,50 +98,345 @@ func main() {
 		log.Fatal("POLKA_KEY environment variable is not set")
 	}
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "uploads"
+	}
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+	chirpMaxLength, err := intFromEnv("CHIRP_MAX_LENGTH", 140)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chirpMaxLengthRed, err := intFromEnv("CHIRP_MAX_LENGTH_RED", 280)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdownTimeout, err := durationFromEnv("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdownDrainDelay, err := durationFromEnv("SHUTDOWN_DRAIN_DELAY", 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	readHeaderTimeout, err := durationFromEnv("READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	readTimeout, err := durationFromEnv("READ_TIMEOUT", 30*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeTimeout, err := durationFromEnv("WRITE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	idleTimeout, err := durationFromEnv("IDLE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxHeaderBytes, err := intFromEnv("MAX_HEADER_BYTES", 64<<10)
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxBodyBytes, err := intFromEnv("MAX_BODY_BYTES", 64<<10)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("Error opening database: %s", err)
 	}
 	dbQueries := database.New(dbConn)
 
+	blobs, err := storage.NewLocalStore(mediaDir, "/media")
+	if err != nil {
+		log.Fatalf("Error creating media directory: %s", err)
+	}
+
+	hub, err := newEventHub(dbURL, dbQueries)
+	if err != nil {
+		log.Fatalf("Error listening for events: %s", err)
+	}
+
+	fed, err := newFederation(dbQueries, publicURL, platform == "dev")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bannedTerms, err := newProfanityFilter(context.Background(), dbQueries)
+	if err != nil {
+		log.Fatalf("Error loading banned terms: %s", err)
+	}
+
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       platform,
-		jwtSecret:      jwtSecret,
-		polkaAPIKey:    polkaAPIKey,
+		fileserverHits:    atomic.Int32{},
+		db:                dbQueries,
+		dbConn:            dbConn,
+		platform:          platform,
+		jwtSecret:         jwtSecret,
+		polkaAPIKey:       polkaAPIKey,
+		blobs:             blobs,
+		profanity:         bannedTerms,
+		spam:              spam.NewDefaultScorer(),
+		events:            hub,
+		federation:        fed,
+		feeds:             newFeedVersions(),
+		publicURL:         publicURL,
+		chirpMaxLength:    chirpMaxLength,
+		chirpMaxLengthRed: chirpMaxLengthRed,
+	}
+
+	// Background workers run until the server has drained its requests, while the event hub stops
+	// as soon as shutdown starts, which ends the realtime streams that would otherwise hold it up.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	streams, stopStreams := context.WithCancel(context.Background())
+	var workersDone sync.WaitGroup
+	startWorker := func(ctx context.Context, run func(ctx context.Context)) {
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			run(ctx)
+		}()
 	}
+	startWorker(workers, func(ctx context.Context) { apiCfg.profanity.Run(ctx, bannedTermsRefreshInterval) })
+	startWorker(workers, func(ctx context.Context) {
+		newChirpPublisher(dbQueries, dbConn, fed).Run(ctx, publishDueChirpsInterval)
+	})
+	startWorker(workers, func(ctx context.Context) { fed.Run(ctx, federationDeliveryInterval) })
+	startWorker(workers, func(ctx context.Context) {
+		newTrendsJob(dbQueries, dbConn).Run(ctx, trendsRefreshInterval)
+	})
+	startWorker(workers, func(ctx context.Context) {
+		newMediaCleanupJob(dbQueries, blobs).Run(ctx, mediaCleanupInterval)
+	})
+	startWorker(streams, hub.Run)
 
 	mux := http.NewServeMux()
 	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	mux.Handle("/app/", fsHandler)
+	mux.Handle("GET /media/", http.StripPrefix("/media/", blobs))
+
+	mux.HandleFunc("GET /api/healthz", apiCfg.handlerReadiness)
+
+	mux.HandleFunc("POST /api/login", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerLogin))
+
+	mux.HandleFunc("POST /api/refresh", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerRefresh))
+
+	mux.HandleFunc("POST /api/revoke", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerRevoke))
 
-	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("POST /api/users", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersCreate))
+	mux.HandleFunc("PUT /api/users",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersUpdate)))
+	mux.HandleFunc("PATCH /api/users/me",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersProfileUpdate)))
+	mux.HandleFunc("PUT /api/users/me/avatar",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxMediaUploadBodyBytes, apiCfg.handlerUsersAvatarUpdate)))
+	mux.HandleFunc("DELETE /api/users/me/avatar", apiCfg.middlewareNotSuspended(apiCfg.handlerUsersAvatarDelete))
+	mux.HandleFunc("PUT /api/users/me/banner",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxMediaUploadBodyBytes, apiCfg.handlerUsersBannerUpdate)))
+	mux.HandleFunc("DELETE /api/users/me/banner", apiCfg.middlewareNotSuspended(apiCfg.handlerUsersBannerDelete))
+	mux.HandleFunc("GET /api/users/{handleOrID}", apiCfg.handlerUsersGet)
+	mux.HandleFunc("POST /api/users/{userID}/follow",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersFollow)))
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.middlewareNotSuspended(apiCfg.handlerUsersUnfollow))
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerUsersFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerUsersFollowing)
+	mux.HandleFunc("POST /api/users/{userID}/block",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersBlock)))
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.middlewareNotSuspended(apiCfg.handlerUsersUnblock))
+	mux.HandleFunc("POST /api/users/{userID}/mute",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersMute)))
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.middlewareNotSuspended(apiCfg.handlerUsersUnmute))
 
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
+	mux.HandleFunc("GET /api/blocks", apiCfg.handlerBlocksList)
+	mux.HandleFunc("GET /api/mutes", apiCfg.handlerMutesList)
 
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
+	mux.HandleFunc("GET /api/users/{userID}/mentions", apiCfg.handlerUsersMentions)
 
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("GET /api/timeline", apiCfg.handlerTimeline)
 
-	mux.HandleFunc("POST /api/users", apiCfg.handlerUsersCreate)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUsersUpdate)
+	mux.HandleFunc("GET /api/stream/chirps", apiCfg.handlerStreamChirps)
+	mux.HandleFunc("GET /api/ws", apiCfg.handlerWebSocket)
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsCreate)
+	mux.HandleFunc("GET /chirps/{chirpID}", apiCfg.handlerChirpPage)
+
+	mux.HandleFunc("GET /feed.atom", apiCfg.handlerFeedsGlobal)
+	mux.HandleFunc("GET /feed.rss", apiCfg.handlerFeedsGlobal)
+	mux.HandleFunc("GET /users/{handleOrID}/feed.atom", apiCfg.handlerFeedsUser)
+	mux.HandleFunc("GET /users/{handleOrID}/feed.rss", apiCfg.handlerFeedsUser)
+	mux.HandleFunc("GET /tags/{tag}/feed.atom", apiCfg.handlerFeedsTag)
+	mux.HandleFunc("GET /tags/{tag}/feed.rss", apiCfg.handlerFeedsTag)
+
+	mux.HandleFunc("GET /.well-known/webfinger", apiCfg.handlerWebFinger)
+	mux.HandleFunc("GET /ap/users/{userID}", apiCfg.handlerAPActor)
+	mux.HandleFunc("GET /ap/users/{userID}/outbox", apiCfg.handlerAPOutbox)
+	mux.HandleFunc("GET /ap/users/{userID}/followers", apiCfg.handlerAPFollowers)
+	mux.HandleFunc("POST /ap/users/{userID}/inbox", middlewareMaxBytes(maxInboxBodySize, apiCfg.handlerAPInbox))
+	mux.HandleFunc("GET /ap/chirps/{chirpID}", apiCfg.handlerAPNote)
+
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerNotificationsList)
+	mux.HandleFunc("POST /api/notifications/read",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerNotificationsRead)))
+	mux.HandleFunc("GET /api/notifications/preferences", apiCfg.handlerNotificationPreferencesGet)
+	mux.HandleFunc("PUT /api/notifications/preferences",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerNotificationPreferencesUpdate)))
+
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerConversationsList)
+	mux.HandleFunc("POST /api/conversations/{userID}/messages",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerMessagesCreate)))
+	mux.HandleFunc("GET /api/conversations/{userID}/messages", apiCfg.handlerMessagesList)
+	mux.HandleFunc("POST /api/conversations/{userID}/read",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerConversationsRead)))
+
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handlerTagsChirps)
+	mux.HandleFunc("GET /api/trends", apiCfg.handlerTrends)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerChirpsReport)))
+	mux.HandleFunc("POST /api/users/{userID}/report",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUsersReport)))
+
+	mux.HandleFunc("POST /api/media",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxMediaUploadBodyBytes, apiCfg.handlerMediaCreate)))
+
+	mux.HandleFunc("POST /api/chirps",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerChirpsCreate)))
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsRetrieve)
+	mux.HandleFunc("GET /api/chirps/scheduled", apiCfg.handlerChirpsScheduledList)
+	mux.HandleFunc("GET /api/chirps/held", apiCfg.handlerChirpsHeldList)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/schedule",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerChirpsScheduledUpdate)))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/schedule",
+		apiCfg.middlewareNotSuspended(apiCfg.handlerChirpsScheduledDelete))
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpsGet)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerChirpsDelete)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.middlewareNotSuspended(apiCfg.handlerChirpsDelete))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerChirpsRechirp)))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp",
+		apiCfg.middlewareNotSuspended(apiCfg.handlerChirpsUndoRechirp))
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/bookmark",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerBookmarksPut)))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark",
+		apiCfg.middlewareNotSuspended(apiCfg.handlerBookmarksDelete))
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUserUpgradeMembership)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.handlerBookmarksList)
+	mux.HandleFunc("GET /api/bookmarks/collections", apiCfg.handlerBookmarkCollectionsList)
+	mux.HandleFunc("POST /api/bookmarks/collections",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerBookmarkCollectionsCreate)))
+	mux.HandleFunc("PUT /api/bookmarks/collections/{collectionID}",
+		apiCfg.middlewareNotSuspended(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerBookmarkCollectionsUpdate)))
+	mux.HandleFunc("DELETE /api/bookmarks/collections/{collectionID}",
+		apiCfg.middlewareNotSuspended(apiCfg.handlerBookmarkCollectionsDelete))
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("POST /api/polka/webhooks", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerUserUpgradeMembership))
+
+	mux.HandleFunc("POST /admin/reset", middlewareMaxBytes(maxBodyBytes, apiCfg.handlerReset))
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.Handle("GET /admin/banned_terms", apiCfg.middlewareAdmin(http.HandlerFunc(apiCfg.handlerBannedTermsList)))
+	mux.Handle("POST /admin/banned_terms",
+		apiCfg.middlewareAdmin(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerBannedTermsCreate)))
+	mux.Handle("PUT /admin/banned_terms/{termID}",
+		apiCfg.middlewareAdmin(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerBannedTermsUpdate)))
+	mux.Handle("DELETE /admin/banned_terms/{termID}",
+		apiCfg.middlewareAdmin(http.HandlerFunc(apiCfg.handlerBannedTermsDelete)))
+	mux.Handle("GET /admin/reports", apiCfg.middlewareAdmin(http.HandlerFunc(apiCfg.handlerReportsList)))
+	mux.Handle("POST /admin/reports/{reportID}/actions",
+		apiCfg.middlewareAdmin(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerReportsAct)))
+	mux.Handle("GET /admin/moderation_actions",
+		apiCfg.middlewareAdmin(http.HandlerFunc(apiCfg.handlerModerationActionsList)))
+	mux.Handle("GET /admin/held_chirps", apiCfg.middlewareAdmin(http.HandlerFunc(apiCfg.handlerHeldChirpsList)))
+	mux.Handle("POST /admin/held_chirps/{chirpID}/approve",
+		apiCfg.middlewareAdmin(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerHeldChirpsApprove)))
+	mux.Handle("POST /admin/held_chirps/{chirpID}/reject",
+		apiCfg.middlewareAdmin(middlewareMaxBytes(maxBodyBytes, apiCfg.handlerHeldChirpsReject)))
 
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:              ":" + port,
+		Handler:           apiCfg.middlewareLogging(mux),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
 	}
+	srv.RegisterOnShutdown(stopStreams)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		slog.Info("Serving", "port", port)
+		err := srv.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
 
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+	<-ctx.Done()
+	// A second signal kills the server right away.
+	stop()
+	shutdown(&apiCfg, srv, shutdownDrainDelay, shutdownTimeout, stopWorkers, &workersDone)
+}
+
+// shutdown stops the server in order: it fails readiness checks and waits drainDelay for load balancers
+// to notice, stops accepting connections and waits up to timeout for in-flight requests, stops the
+// background workers, and finally closes the database pool.
+func shutdown(
+	cfg *apiConfig,
+	srv *http.Server,
+	drainDelay, timeout time.Duration,
+	stopWorkers context.CancelFunc,
+	workersDone *sync.WaitGroup,
+) {
+	slog.Info("Shutting down")
+	cfg.draining.Store(true)
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		slog.Error("Couldn't drain connections", "error", err)
+		_ = srv.Close()
+	}
+
+	stopWorkers()
+	workersDone.Wait()
+
+	err = cfg.dbConn.Close()
+	if err != nil {
+		slog.Error("Couldn't close database", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// intFromEnv reads a positive integer from an environment variable,
+// falling back to a default value when the variable is not set.
+func intFromEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+// durationFromEnv reads a non-negative duration such as "30s" from an environment variable,
+// falling back to a default value when the variable is not set.
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, such as 30s", key)
+	}
+	return d, nil
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/storage"
+)
+
+const (
+	// mediaCleanupInterval is how often uploads that were never attached to a chirp are removed.
+	// DeleteUnattachedMedia removes the uploads older than 24 hours.
+	mediaCleanupInterval = time.Hour
+	// mediaCleanupBatchSize is the maximum number of uploads removed in a single query.
+	mediaCleanupBatchSize = 100
+)
+
+// mediaCleanupJob removes the uploads that were never attached to a chirp, along with their files.
+type mediaCleanupJob struct {
+	db    *database.Queries
+	blobs storage.BlobStore
+}
+
+// newMediaCleanupJob creates a cleanup job backed by the given queries and blob store.
+func newMediaCleanupJob(db *database.Queries, blobs storage.BlobStore) *mediaCleanupJob {
+	return &mediaCleanupJob{db: db, blobs: blobs}
+}
+
+// Clean removes the unattached uploads, in batches, and returns how many were removed. Their rows are
+// deleted first, so that a file is never missing for an attachment that still exists.
+func (j *mediaCleanupJob) Clean(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		removed, err := j.db.DeleteUnattachedMedia(ctx, mediaCleanupBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, attachment := range removed {
+			for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+				err = j.blobs.Delete(ctx, key)
+				if err != nil {
+					slog.ErrorContext(ctx, "Couldn't delete blob", "key", key, "error", err)
+				}
+			}
+		}
+		total += len(removed)
+		if len(removed) < mediaCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Run removes unattached uploads immediately and then periodically until ctx is done.
+func (j *mediaCleanupJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := j.Clean(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't remove unattached media", "error", err)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "Removed unattached media", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+)
+
+// middlewareAdmin is an HTTP middleware that only lets requests from administrators through.
+// It validates the JWT of the request and checks the user's admin flag.
+func (cfg *apiConfig) middlewareAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, r, http.StatusUnauthorized, "Couldn't find JWT", err)
+			return
+		}
+		userID, err := cfg.validateJWT(r.Context(), token)
+		if err != nil {
+			respondWithError(w, r, http.StatusUnauthorized, "Couldn't validate JWT", err)
+			return
+		}
+
+		user, err := cfg.db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, r, http.StatusUnauthorized, "Couldn't get user", err)
+			return
+		}
+		if !user.IsAdmin {
+			respondWithError(w, r, http.StatusForbidden, "Admin access required", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/logging"
+	"github.com/google/uuid"
+)
+
+// middlewareLogging is an HTTP middleware that tags every request with an ID and writes an access log line
+// once it has been served. The ID comes from the X-Request-ID header when the client sends a valid one
+// and is generated otherwise; it is echoed in the response and added to every log of the request.
+// The access log names the user the handler authenticated, if any (see validateJWT).
+func (cfg *apiConfig) middlewareLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get("X-Request-ID")
+		if !logging.ValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		r = r.WithContext(logging.WithRequestUser(logging.WithRequestID(r.Context(), requestID)))
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// The mux sets the pattern of the route on the request it was given.
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", r.Pattern),
+			slog.Int("status", rec.statusCode()),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int64("bytes", rec.bytes),
+		}
+		if userID := logging.UserID(r.Context()); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		slog.LogAttrs(r.Context(), slog.LevelInfo, "Request served", attrs...)
+	})
+}
+
+// responseRecorder is an http.ResponseWriter that records the status code and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	// status is the status code written, or 0 if the headers haven't been written yet.
+	status int
+	// bytes is the number of body bytes written.
+	bytes int64
+}
+
+// WriteHeader records the status code and writes the headers.
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the size of the body written.
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying response writer, so that an http.ResponseController can flush
+// the response and set its deadlines.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Hijack takes over the connection for WebSocket upgrades, which the response is then switched to.
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// statusCode returns the status code of the response, which is 200 when the handler wrote nothing.
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
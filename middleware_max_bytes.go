@@ -0,0 +1,12 @@
+package main
+
+import "net/http"
+
+// middlewareMaxBytes is an HTTP middleware that caps the size of the request body at limit bytes.
+// Reading past the limit fails with an *http.MaxBytesError, which handlers answer with a 413.
+func middlewareMaxBytes(limit int, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+		next(w, r)
+	}
+}
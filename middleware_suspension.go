@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+)
+
+// middlewareNotSuspended is an HTTP middleware that refuses the requests of suspended users with 403.
+// It guards every route that changes state, so that a suspended user's outstanding access tokens only
+// give read access. Requests without a valid JWT are passed on for the handler to reject or serve, while
+// a token whose user no longer exists is refused with 401.
+func (cfg *apiConfig) middlewareNotSuspended(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		userID, err := cfg.validateJWT(r.Context(), token)
+		if err != nil {
+			next(w, r)
+			return
+		}
+		user, err := cfg.db.GetUserByID(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, http.StatusUnauthorized, "Couldn't find user", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		if isSuspended(user) {
+			respondWithSuspended(w, r, user)
+			return
+		}
+		next(w, r)
+	}
+}
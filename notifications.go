@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/google/uuid"
+)
+
+// notificationType identifies the kind of event a notification is about.
+type notificationType string
+
+const (
+	// notificationMention is sent to a user mentioned in a chirp.
+	notificationMention notificationType = "mention"
+	// notificationQuote is sent to the author of a quoted chirp.
+	notificationQuote notificationType = "quote"
+	// notificationRechirp is sent to the author of a rechirped chirp.
+	notificationRechirp notificationType = "rechirp"
+	// notificationFollow is sent to a user who gained a follower.
+	notificationFollow notificationType = "follow"
+	// notificationMembership is sent to a user whose membership was upgraded to Chirpy Red.
+	notificationMembership notificationType = "membership"
+	// notificationModeration is sent to a user a moderator warned, suspended or hid a chirp of.
+	notificationModeration notificationType = "moderation"
+)
+
+// notificationTypeInfo describes an entry of the notification type registry.
+type notificationTypeInfo struct {
+	// Description explains the type to users choosing their preferences.
+	Description string
+	// EnabledByDefault tells whether users who never set a preference for the type receive it.
+	EnabledByDefault bool
+}
+
+// notificationTypes is the registry of notification types. Adding a type takes an entry here
+// and a call to notify where its event happens; preferences pick it up automatically.
+var notificationTypes = map[notificationType]notificationTypeInfo{
+	notificationMention:    {Description: "Someone mentioned you in a chirp", EnabledByDefault: true},
+	notificationQuote:      {Description: "Someone quoted one of your chirps", EnabledByDefault: true},
+	notificationRechirp:    {Description: "Someone rechirped one of your chirps", EnabledByDefault: true},
+	notificationFollow:     {Description: "Someone followed you", EnabledByDefault: true},
+	notificationMembership: {Description: "Your Chirpy Red membership was activated", EnabledByDefault: true},
+	notificationModeration: {Description: "A moderator acted on your account or chirps", EnabledByDefault: true},
+}
+
+// notification is an event to notify a user of.
+type notification struct {
+	// UserID is the recipient of the notification.
+	UserID uuid.UUID
+	// Type is the kind of event.
+	Type notificationType
+	// ActorID is the user who caused the event, if any.
+	ActorID uuid.NullUUID
+	// ChirpID is the chirp the event is about, if any.
+	ChirpID uuid.NullUUID
+}
+
+// notify records a notification. It is silently dropped when the recipient is the actor,
+// turned the type off, blocks or is blocked by the actor, muted the actor, or can't see the chirp.
+func notify(ctx context.Context, q *database.Queries, n notification) error {
+	info, ok := notificationTypes[n.Type]
+	if !ok {
+		return fmt.Errorf("unknown notification type %q", n.Type)
+	}
+	return q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:           n.UserID,
+		Type:             string(n.Type),
+		ActorID:          n.ActorID,
+		ChirpID:          n.ChirpID,
+		EnabledByDefault: info.EnabledByDefault,
+	})
+}
+
+// notifyChirpPublished notifies the users mentioned in a newly published chirp
+// and the author of the chirp it quotes.
+func notifyChirpPublished(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	actor := asViewer(chirp.UserID)
+	chirpID := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+
+	mentions, err := q.GetMentionsForChirps(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		return err
+	}
+	notified := make(map[uuid.UUID]bool, len(mentions))
+	for _, mention := range mentions {
+		if notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+		err = notify(ctx, q, notification{
+			UserID:  mention.UserID,
+			Type:    notificationMention,
+			ActorID: actor,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !chirp.QuoteOf.Valid {
+		return nil
+	}
+	quoted, err := q.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		Ids:      []uuid.UUID{chirp.QuoteOf.UUID},
+		ViewerID: actor,
+	})
+	if err != nil {
+		return err
+	}
+	for _, quotedChirp := range quoted {
+		err = notify(ctx, q, notification{
+			UserID:  quotedChirp.UserID,
+			Type:    notificationQuote,
+			ActorID: actor,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
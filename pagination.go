@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultPageLimit is the page size used when the client does not provide one.
+	defaultPageLimit = 20
+	// maxPageLimit caps the page size a client may request.
+	maxPageLimit = 100
+)
+
+// page holds the keyset pagination parameters of a list request.
+// A zero cursor (both fields invalid) requests the first page.
+type page struct {
+	// CursorCreatedAt is the creation time of the last item of the previous page.
+	CursorCreatedAt sql.NullTime
+	// CursorID is the ID of the last item of the previous page, used to break ties.
+	CursorID uuid.NullUUID
+	// Limit is the maximum number of items to return.
+	Limit int32
+}
+
+// parsePage reads the "cursor" and "limit" query parameters of a list request.
+// It returns an error if either parameter is malformed.
+func parsePage(r *http.Request) (page, error) {
+	p := page{Limit: defaultPageLimit}
+
+	limitString := r.URL.Query().Get("limit")
+	if limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 {
+			return page{}, errors.New("Invalid limit, must be a positive integer")
+		}
+		p.Limit = int32(min(limit, maxPageLimit))
+	}
+
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		return p, nil
+	}
+	createdAt, id, err := decodeCursor(cursor)
+	if err != nil {
+		return page{}, errors.New("Invalid cursor")
+	}
+	p.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+	p.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	return p, nil
+}
+
+// nextCursor returns the cursor pointing after the given item when the page is full,
+// or an empty string when there are no more items to fetch.
+func (p page) nextCursor(count int, createdAt time.Time, id uuid.UUID) string {
+	if count < int(p.Limit) {
+		return ""
+	}
+	return encodeCursor(createdAt, id)
+}
+
+// encodeCursor builds an opaque cursor from an item's creation time and ID.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	createdAtString, idString, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return createdAt, id, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/quota"
+)
+
+var (
+	// postingQuotas are the rolling limits on the number of chirps a user can post.
+	postingQuotas = []quota.Window{
+		{Limit: 30, Period: time.Hour},
+		{Limit: 200, Period: 24 * time.Hour},
+	}
+	// postingQuotasRed are the rolling limits for Chirpy Red members.
+	postingQuotasRed = []quota.Window{
+		{Limit: 100, Period: time.Hour},
+		{Limit: 1000, Period: 24 * time.Hour},
+	}
+)
+
+// postingQuotaWindow is the longest period of the posting quotas, beyond which posting events are forgotten.
+const postingQuotaWindow = 24 * time.Hour
+
+// reservePost checks a user's posting quotas and, if they allow a new chirp, records it.
+// It must run in the transaction creating the chirp: it takes a lock on the user's quota until the
+// transaction ends, so concurrent requests, even on other server instances, can't exceed the limits,
+// and a rolled back chirp doesn't count. The returned status describes the quota after the new chirp.
+func reservePost(ctx context.Context, q *database.Queries, user database.User) (quota.Status, error) {
+	windows := postingQuotas
+	if user.IsChirpyRed {
+		windows = postingQuotasRed
+	}
+
+	err := q.LockUserPosting(ctx, user.ID)
+	if err != nil {
+		return quota.Status{}, err
+	}
+	now := time.Now().UTC()
+	since := now.Add(-postingQuotaWindow)
+	err = q.DeletePostingEventsBefore(ctx, database.DeletePostingEventsBeforeParams{UserID: user.ID, CreatedAt: since})
+	if err != nil {
+		return quota.Status{}, err
+	}
+	events, err := q.GetPostingEvents(ctx, database.GetPostingEventsParams{UserID: user.ID, CreatedAt: since})
+	if err != nil {
+		return quota.Status{}, err
+	}
+
+	status := quota.Check(now, events, windows)
+	if !status.Allowed {
+		return status, nil
+	}
+	err = q.CreatePostingEvent(ctx, database.CreatePostingEventParams{UserID: user.ID, CreatedAt: now})
+	if err != nil {
+		return quota.Status{}, err
+	}
+	after := quota.Check(now, append(events, now), windows)
+	after.Allowed = true
+	return after, nil
+}
+
+// setRateLimitHeaders describes a quota in the X-RateLimit-* headers of a response,
+// and adds Retry-After when the quota is exhausted.
+func setRateLimitHeaders(w http.ResponseWriter, s quota.Status) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.Reset.Unix(), 10))
+	if !s.Allowed {
+		retryAfter := int(math.Ceil(time.Until(s.Reset).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, retryAfter)))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/profanity"
+)
+
+// bannedTermsRefreshInterval is how often the banned terms are reloaded, so that changes
+// made through another server instance are picked up.
+const bannedTermsRefreshInterval = time.Minute
+
+// profanityFilter caches the compiled list of banned terms stored in the database.
+type profanityFilter struct {
+	db     *database.Queries
+	filter atomic.Pointer[profanity.Filter]
+}
+
+// newProfanityFilter creates a cache loaded with the banned terms currently stored in the database,
+// so that no chirp is accepted before they are known.
+func newProfanityFilter(ctx context.Context, db *database.Queries) (*profanityFilter, error) {
+	f := &profanityFilter{db: db}
+	err := f.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load returns the current filter.
+func (f *profanityFilter) Load() *profanity.Filter {
+	return f.filter.Load()
+}
+
+// Refresh reloads the banned terms from the database and swaps in a new filter.
+func (f *profanityFilter) Refresh(ctx context.Context) error {
+	dbTerms, err := f.db.ListBannedTerms(ctx)
+	if err != nil {
+		return err
+	}
+	terms := make([]profanity.Term, 0, len(dbTerms))
+	for _, dbTerm := range dbTerms {
+		terms = append(terms, profanity.Term{Text: dbTerm.Term, Mode: profanity.Mode(dbTerm.Mode)})
+	}
+	f.filter.Store(profanity.New(terms))
+	return nil
+}
+
+// Run refreshes the filter periodically until ctx is done. The filter is already loaded when it is created.
+func (f *profanityFilter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := f.Refresh(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't refresh banned terms", "error", err)
+		}
+	}
+}
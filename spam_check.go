@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/entities"
+	"github.com/alnah/go-httpserver/internal/spam"
+)
+
+// spamRecentChirps is the number of the author's recent chirps a new chirp is compared with.
+// GetRecentChirpBodies only returns the chirps of the last 24 hours.
+const spamRecentChirps = 20
+
+// scoreChirp runs the spam checks against a chirp the user is about to post.
+func (cfg *apiConfig) scoreChirp(ctx context.Context, user database.User, body string) (spam.Verdict, error) {
+	recent, err := cfg.db.GetRecentChirpBodies(ctx, database.GetRecentChirpBodiesParams{
+		UserID: user.ID,
+		Limit:  spamRecentChirps,
+	})
+	if err != nil {
+		return spam.Verdict{}, err
+	}
+	// The account age is measured by the database, whose clock set created_at.
+	ageSeconds, err := cfg.db.GetUserAccountAge(ctx, user.ID)
+	if err != nil {
+		return spam.Verdict{}, err
+	}
+
+	mentions := 0
+	for _, e := range entities.Parse(body) {
+		if e.Kind == entities.KindMention {
+			mentions++
+		}
+	}
+	return cfg.spam.Score(spam.Input{
+		Body:         body,
+		RecentBodies: recent,
+		Mentions:     mentions,
+		AccountAge:   time.Duration(ageSeconds) * time.Second,
+	}), nil
+}
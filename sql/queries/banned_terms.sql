@@ -0,0 +1,38 @@
+-- name: ListBannedTerms :many
+SELECT * FROM banned_terms
+ORDER BY term ASC;
+
+-- name: CreateBannedTerm :one
+INSERT INTO banned_terms (id, created_at, updated_at, term, mode)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: UpdateBannedTermMode :one
+UPDATE banned_terms
+SET mode = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteBannedTerm :execrows
+DELETE FROM banned_terms
+WHERE id = $1;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, created_at, chirp_id, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+);
+
+-- name: DeleteChirpFlags :exec
+DELETE FROM chirp_flags
+WHERE chirp_id = $1;
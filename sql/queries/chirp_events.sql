@@ -0,0 +1,23 @@
+-- name: GetChirpEvent :one
+SELECT * FROM chirp_events
+WHERE id = $1;
+
+-- name: GetChirpEventsAfter :many
+SELECT * FROM chirp_events
+WHERE id > sqlc.arg('after_id')
+ORDER BY id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpEventAccess :one
+SELECT
+    chirp_in_feed_of(user_id, visibility, sqlc.narg('viewer_id')::uuid) AS visible,
+    EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = sqlc.narg('viewer_id')::uuid AND follows.followee_id = chirp_events.user_id
+    ) AS followed
+FROM chirp_events
+WHERE id = sqlc.arg('id');
+
+-- name: DeleteExpiredChirpEvents :exec
+DELETE FROM chirp_events
+WHERE created_at < NOW() - INTERVAL '24 hours';
@@ -1,27 +1,122 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, quote_of, status, publish_at, visibility, language)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
 -- name: GetChirps :many
 SELECT * FROM chirps
+WHERE status = 'published'
+AND chirp_in_feed_of(user_id, visibility, sqlc.narg('viewer_id')::uuid)
 ORDER BY created_at ASC;
 
 -- name: GetChirpsByUserID :many
 SELECT * FROM chirps
-WHERE user_id = $1
+WHERE user_id = sqlc.arg('user_id') AND status = 'published'
+AND chirp_visible_to(user_id, visibility, sqlc.narg('viewer_id')::uuid)
 ORDER BY created_at ASC;
 
 -- name: GetChirp :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = sqlc.arg('id') AND status = 'published'
+AND chirp_visible_to(user_id, visibility, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetChirpIncludingUnpublished :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg('id')
+AND (
+    (status = 'published' AND chirp_visible_to(user_id, visibility, sqlc.narg('viewer_id')::uuid))
+    OR user_id = sqlc.narg('viewer_id')::uuid
+    OR EXISTS (SELECT 1 FROM users WHERE users.id = sqlc.narg('viewer_id')::uuid AND users.is_admin)
+);
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1 AND user_id = $2;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]) AND status = 'published'
+AND chirp_visible_to(user_id, visibility, sqlc.narg('viewer_id')::uuid);
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, repost_of)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    '',
+    $1,
+    $2
+)
+ON CONFLICT (user_id, repost_of) WHERE repost_of IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND repost_of = COALESCE(
+    (SELECT shared.repost_of FROM chirps AS shared WHERE shared.id = sqlc.arg('chirp_id')),
+    sqlc.arg('chirp_id')
+);
+
+-- name: GetShareCounts :many
+SELECT
+    original.id AS chirp_id,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.repost_of = original.id) AS rechirp_count,
+    (
+        SELECT COUNT(*) FROM chirps
+        WHERE chirps.quote_of = original.id AND chirps.status = 'published'
+    ) AS quote_count
+FROM chirps AS original
+WHERE original.id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetScheduledChirpsByUserID :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND status = 'scheduled'
+ORDER BY publish_at ASC, id ASC;
+
+-- name: GetHeldChirpsByUserID :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND status = 'held'
+ORDER BY created_at DESC, id DESC;
+
+-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = $3, publish_at = $4, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled'
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled';
+
+-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled' AND publish_at <= sqlc.arg('now')
+    ORDER BY publish_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE chirps
+SET status = 'published', created_at = NOW(), updated_at = NOW(), publish_at = NULL
+FROM due
+WHERE chirps.id = due.id
+RETURNING chirps.*;
+
+-- name: GetRecentChirpBodies :many
+SELECT body FROM chirps
+WHERE user_id = sqlc.arg('user_id') AND created_at >= NOW() - INTERVAL '24 hours' AND repost_of IS NULL
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,69 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: CreateChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, start_index, end_index)
+VALUES ($1, $2, $3, $4);
+
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_index, end_index)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetHashtagsForChirps :many
+SELECT chirp_hashtags.chirp_id, hashtags.name, chirp_hashtags.start_index, chirp_hashtags.end_index
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_hashtags.chirp_id, chirp_hashtags.start_index;
+
+-- name: GetMentionsForChirps :many
+SELECT chirp_id, user_id, start_index, end_index
+FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_index;
+
+-- name: GetChirpsByHashtag :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_hashtags.chirp_id FROM chirp_hashtags
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    WHERE hashtags.name = sqlc.arg('name')
+)
+AND status = 'published'
+AND chirp_in_feed_of(user_id, visibility, sqlc.narg('viewer_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsMentioningUser :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_id FROM chirp_mentions
+    WHERE chirp_mentions.user_id = sqlc.arg('user_id')
+)
+AND status = 'published'
+AND chirp_in_feed_of(user_id, visibility, sqlc.narg('viewer_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,83 @@
+-- name: GetActorKey :one
+SELECT * FROM actor_keys
+WHERE user_id = $1;
+
+-- name: CreateActorKey :exec
+INSERT INTO actor_keys (user_id, created_at, private_key_pem, public_key_pem)
+VALUES ($1, NOW(), $2, $3)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UpsertRemoteActor :one
+INSERT INTO remote_actors (id, created_at, updated_at, uri, inbox, shared_inbox, public_key_id, public_key_pem)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+ON CONFLICT (uri) DO UPDATE SET
+    updated_at = NOW(),
+    inbox = EXCLUDED.inbox,
+    shared_inbox = EXCLUDED.shared_inbox,
+    public_key_id = EXCLUDED.public_key_id,
+    public_key_pem = EXCLUDED.public_key_pem
+RETURNING *;
+
+-- name: GetRemoteActorByKeyID :one
+SELECT * FROM remote_actors
+WHERE public_key_id = $1
+ORDER BY updated_at DESC
+LIMIT 1;
+
+-- name: CreateRemoteFollow :exec
+INSERT INTO remote_follows (user_id, remote_actor_id, created_at, follow_activity_id)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, remote_actor_id) DO UPDATE SET follow_activity_id = EXCLUDED.follow_activity_id;
+
+-- name: DeleteRemoteFollow :execrows
+DELETE FROM remote_follows
+WHERE user_id = $1 AND remote_actor_id = $2 AND follow_activity_id = $3;
+
+-- name: CountRemoteFollowers :one
+SELECT COUNT(*) FROM remote_follows
+WHERE user_id = $1;
+
+-- name: CountPublicChirpsByUserID :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1 AND status = 'published' AND visibility = 'public' AND repost_of IS NULL;
+
+-- name: EnqueueDelivery :exec
+INSERT INTO federation_deliveries (id, created_at, user_id, inbox, activity, next_attempt_at)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4);
+
+-- name: EnqueueDeliveriesToFollowers :exec
+INSERT INTO federation_deliveries (id, created_at, user_id, inbox, activity, next_attempt_at)
+SELECT
+    gen_random_uuid(),
+    NOW(),
+    sqlc.arg('user_id')::uuid,
+    inboxes.inbox,
+    sqlc.arg('activity')::text,
+    sqlc.arg('next_attempt_at')::timestamp
+FROM (
+    SELECT DISTINCT COALESCE(remote_actors.shared_inbox, remote_actors.inbox) AS inbox
+    FROM remote_follows
+    JOIN remote_actors ON remote_actors.id = remote_follows.remote_actor_id
+    WHERE remote_follows.user_id = sqlc.arg('user_id')::uuid
+) AS inboxes;
+
+-- name: ClaimDueDeliveries :many
+UPDATE federation_deliveries
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT id FROM federation_deliveries
+    WHERE next_attempt_at <= sqlc.arg('now')
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: DeleteDelivery :exec
+DELETE FROM federation_deliveries
+WHERE id = $1;
+
+-- name: RetryDelivery :exec
+UPDATE federation_deliveries
+SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+WHERE id = $1;
@@ -0,0 +1,22 @@
+-- name: GetPublicChirps :many
+SELECT * FROM chirps
+WHERE status = 'published' AND visibility = 'public' AND repost_of IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
+
+-- name: GetPublicChirpsByUserID :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND status = 'published' AND visibility = 'public' AND repost_of IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: GetPublicChirpsByHashtag :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_hashtags.chirp_id FROM chirp_hashtags
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    WHERE hashtags.name = $1
+)
+AND status = 'published' AND visibility = 'public' AND repost_of IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
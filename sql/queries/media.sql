@@ -0,0 +1,43 @@
+-- name: CreateMediaAttachment :one
+INSERT INTO media_attachments (
+    id, created_at, updated_at, user_id, content_type, width, height,
+    size_bytes, storage_key, thumbnail_key, alt_text
+)
+VALUES (
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: AttachMediaToChirp :execrows
+UPDATE media_attachments
+SET chirp_id = sqlc.arg('chirp_id'),
+    position = sqlc.arg('position'),
+    alt_text = COALESCE(sqlc.narg('alt_text'), alt_text),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id') AND chirp_id IS NULL;
+
+-- name: GetMediaAttachmentsForChirps :many
+SELECT * FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteUnattachedMedia :many
+DELETE FROM media_attachments
+WHERE id IN (
+    SELECT id FROM media_attachments
+    WHERE chirp_id IS NULL AND created_at < NOW() - INTERVAL '24 hours'
+    ORDER BY created_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
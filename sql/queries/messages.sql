@@ -0,0 +1,91 @@
+-- name: UpsertConversation :one
+INSERT INTO conversations (id, created_at, updated_at, user_a_id, user_b_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+ON CONFLICT (user_a_id, user_b_id) DO UPDATE SET updated_at = conversations.updated_at
+RETURNING *;
+
+-- name: CreateConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationBetween :one
+SELECT * FROM conversations
+WHERE user_a_id = $1 AND user_b_id = $2;
+
+-- name: GetConversationParticipants :many
+SELECT * FROM conversation_participants
+WHERE conversation_id = $1;
+
+-- name: GetConversationsForUser :many
+SELECT
+    conversations.id,
+    conversations.created_at,
+    conversations.updated_at,
+    other.user_id AS other_user_id,
+    me.last_read_at,
+    other.last_read_at AS other_last_read_at,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> me.user_id
+        AND (me.last_read_at IS NULL OR messages.created_at > me.last_read_at)
+    ) AS unread_count
+FROM conversation_participants AS me
+JOIN conversations ON conversations.id = me.conversation_id
+JOIN conversation_participants AS other
+    ON other.conversation_id = me.conversation_id AND other.user_id <> me.user_id
+WHERE me.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (conversations.updated_at, conversations.id)
+        < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_participants
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: CreateMessageFlag :exec
+INSERT INTO message_flags (id, created_at, message_id, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2
+);
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
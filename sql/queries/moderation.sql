@@ -0,0 +1,107 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, user_id, chirp_id, reason, comment)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE status = sqlc.arg('status')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetReportForUpdate :one
+SELECT * FROM reports
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CloseReport :one
+UPDATE reports
+SET status = $2,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: HideChirp :one
+UPDATE chirps
+SET status = 'hidden',
+    updated_at = NOW()
+WHERE id = $1 AND status = 'published'
+RETURNING *;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_until = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (
+    id, created_at, moderator_id, report_id, action, user_id, chirp_id, note, suspended_until
+)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListHeldChirps :many
+SELECT * FROM chirps
+WHERE status = 'held'
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpFlagsForChirps :many
+SELECT * FROM chirp_flags
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY created_at;
+
+-- name: ApproveHeldChirp :one
+UPDATE chirps
+SET status = CASE WHEN publish_at > sqlc.arg('now') THEN 'scheduled' ELSE 'published' END,
+    created_at = CASE WHEN publish_at > sqlc.arg('now') THEN created_at ELSE NOW() END,
+    publish_at = CASE WHEN publish_at > sqlc.arg('now') THEN publish_at END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND status = 'held'
+RETURNING *;
+
+-- name: RejectHeldChirp :one
+UPDATE chirps
+SET status = 'hidden',
+    updated_at = NOW()
+WHERE id = $1 AND status = 'held'
+RETURNING *;
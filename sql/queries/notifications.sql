@@ -0,0 +1,75 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (id, created_at, user_id, type, actor_id, chirp_id)
+SELECT
+    gen_random_uuid(),
+    NOW(),
+    sqlc.arg('user_id')::uuid,
+    sqlc.arg('type')::text,
+    sqlc.narg('actor_id')::uuid,
+    sqlc.narg('chirp_id')::uuid
+WHERE COALESCE(
+    (
+        SELECT enabled FROM notification_preferences
+        WHERE notification_preferences.user_id = sqlc.arg('user_id')
+        AND notification_preferences.type = sqlc.arg('type')
+    ),
+    sqlc.arg('enabled_by_default')::boolean
+)
+AND (
+    sqlc.narg('actor_id')::uuid IS NULL
+    OR (
+        sqlc.narg('actor_id')::uuid <> sqlc.arg('user_id')
+        AND NOT users_blocked(sqlc.arg('user_id'), sqlc.narg('actor_id')::uuid)
+        AND NOT EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = sqlc.arg('user_id') AND mutes.muted_id = sqlc.narg('actor_id')::uuid
+        )
+    )
+)
+AND (
+    sqlc.narg('chirp_id')::uuid IS NULL
+    OR EXISTS (
+        SELECT 1 FROM chirps
+        WHERE chirps.id = sqlc.narg('chirp_id')::uuid
+        AND chirp_visible_to(chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+    )
+);
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+AND (actor_id IS NULL OR NOT users_blocked(user_id, actor_id))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+AND (actor_id IS NULL OR NOT users_blocked(user_id, actor_id));
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND id = ANY(sqlc.arg('ids')::uuid[]) AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW();
+
+-- name: GetNotification :one
+SELECT * FROM notifications
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,15 @@
+-- name: LockUserPosting :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg('user_id')::uuid::text, 0));
+
+-- name: GetPostingEvents :many
+SELECT created_at FROM posting_events
+WHERE user_id = $1 AND created_at > $2
+ORDER BY created_at;
+
+-- name: CreatePostingEvent :exec
+INSERT INTO posting_events (id, user_id, created_at)
+VALUES (gen_random_uuid(), $1, $2);
+
+-- name: DeletePostingEventsBefore :exec
+DELETE FROM posting_events
+WHERE user_id = $1 AND created_at <= $2;
@@ -0,0 +1,45 @@
+-- name: GetHashtagUsage :many
+WITH bounds AS (
+    SELECT
+        NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8) AS window_start,
+        NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8 + sqlc.arg('baseline_seconds')::float8)
+            AS baseline_start
+)
+SELECT
+    hashtags.name,
+    (CASE WHEN GROUPING(chirps.language) = 1 THEN '' ELSE chirps.language END)::text AS language,
+    COUNT(DISTINCT chirps.id) FILTER (WHERE chirps.created_at >= bounds.window_start) AS current_uses,
+    COUNT(DISTINCT chirps.id) FILTER (WHERE chirps.created_at < bounds.window_start) AS baseline_uses,
+    COUNT(DISTINCT chirps.user_id) FILTER (WHERE chirps.created_at >= bounds.window_start) AS current_authors
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+CROSS JOIN bounds
+WHERE chirps.created_at >= bounds.baseline_start
+AND chirps.status = 'published'
+AND chirps.visibility = 'public'
+AND chirps.repost_of IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM chirp_flags
+    JOIN chirps AS flagged ON flagged.id = chirp_flags.chirp_id
+    WHERE flagged.user_id = chirps.user_id AND chirp_flags.created_at >= bounds.baseline_start
+)
+AND (SELECT COUNT(*) FROM blocks WHERE blocks.blocked_id = chirps.user_id) < sqlc.arg('max_blocks')::bigint
+GROUP BY GROUPING SETS ((hashtags.name), (hashtags.name, chirps.language))
+HAVING GROUPING(chirps.language) = 1 OR chirps.language <> '';
+
+-- name: LockTrendsRefresh :exec
+SELECT pg_advisory_xact_lock(hashtextextended('trends', 0));
+
+-- name: DeleteTrends :exec
+DELETE FROM trends;
+
+-- name: CreateTrend :exec
+INSERT INTO trends (language, rank, hashtag, score, uses, authors, computed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetTrends :many
+SELECT * FROM trends
+WHERE language = $1
+ORDER BY rank
+LIMIT $2;
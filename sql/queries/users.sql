@@ -1,13 +1,17 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES (
-    gen_random_uuid(),
+WITH new_user AS (
+    SELECT gen_random_uuid() AS id
+)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
+SELECT
+    new_user.id,
     NOW(),
     NOW(),
-    $1,
-    $2
-)
-RETURNING id, email, is_chirpy_red, created_at, updated_at;
+    sqlc.arg('email'),
+    sqlc.arg('hashed_password'),
+    COALESCE(sqlc.narg('handle')::text, 'user_' || LEFT(REPLACE(new_user.id::text, '-', ''), 12))
+FROM new_user
+RETURNING *;
 
 -- name: GetUserByEmail :one
 SELECT *
@@ -20,10 +24,57 @@ SET email = $2,
     hashed_password = $3,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, is_chirpy_red, created_at, updated_at;
+RETURNING *;
 
 -- name: UpgradeUserMembership :exec
 UPDATE users
 SET is_chirpy_red = true,
     updated_at = NOW()
 WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: GetUserAccountAge :one
+SELECT EXTRACT(EPOCH FROM NOW() - created_at)::bigint AS age_seconds
+FROM users
+WHERE id = $1;
+
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUsersByIDs :many
+SELECT *
+FROM users
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2,
+    display_name = $3,
+    bio = $4,
+    location = $5,
+    website = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserAvatarKey :one
+UPDATE users
+SET avatar_key = $2,
+    updated_at = NOW()
+FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) AS old
+WHERE users.id = old.id
+RETURNING sqlc.embed(users), old.avatar_key AS old_avatar_key;
+
+-- name: SetUserBannerKey :one
+UPDATE users
+SET banner_key = $2,
+    updated_at = NOW()
+FROM (SELECT id, banner_key FROM users WHERE id = $1 FOR UPDATE) AS old
+WHERE users.id = old.id
+RETURNING sqlc.embed(users), old.banner_key AS old_banner_key;
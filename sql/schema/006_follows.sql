@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_idx ON follows (followee_id, created_at DESC);
+
+-- Timeline reads walk recent chirps and probe follows, or walk each followed
+-- author's chirps, depending on how many accounts the caller follows.
+CREATE INDEX chirps_created_at_idx ON chirps (created_at DESC, id DESC);
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX chirps_user_id_created_at_idx;
+DROP INDEX chirps_created_at_idx;
+DROP TABLE follows;
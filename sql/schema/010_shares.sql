@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN repost_of UUID REFERENCES chirps(id) ON DELETE CASCADE;
+
+ALTER TABLE chirps
+ADD COLUMN quote_of UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX chirps_user_id_repost_of_idx ON chirps (user_id, repost_of)
+WHERE repost_of IS NOT NULL;
+CREATE INDEX chirps_repost_of_idx ON chirps (repost_of) WHERE repost_of IS NOT NULL;
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of) WHERE quote_of IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN quote_of;
+
+ALTER TABLE chirps
+DROP COLUMN repost_of;
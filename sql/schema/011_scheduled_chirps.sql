@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+CONSTRAINT chirps_status_check CHECK (status IN ('published', 'scheduled'));
+
+ALTER TABLE chirps
+ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at) WHERE status = 'scheduled';
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN publish_at;
+
+ALTER TABLE chirps
+DROP COLUMN status;
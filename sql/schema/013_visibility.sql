@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CONSTRAINT chirps_visibility_check CHECK (visibility IN ('public', 'followers', 'private'));
+
+-- chirp_visible_to reports whether a viewer may see a chirp of the given author and visibility.
+-- A NULL viewer is an anonymous caller, who only sees public chirps.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE SQL
+STABLE
+AS $$
+    SELECT visibility = 'public'
+        OR (viewer_id IS NOT NULL AND viewer_id = author_id)
+        OR (
+            visibility = 'followers'
+            AND viewer_id IS NOT NULL
+            AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+            )
+        );
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to(UUID, TEXT, UUID);
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
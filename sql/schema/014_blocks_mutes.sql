@@ -0,0 +1,101 @@
+-- +goose Up
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+-- users_blocked reports whether either user blocks the other.
+-- +goose StatementBegin
+CREATE FUNCTION users_blocked(user_id UUID, other_id UUID)
+RETURNS BOOLEAN
+LANGUAGE SQL
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = user_id AND blocks.blocked_id = other_id)
+        OR (blocks.blocker_id = other_id AND blocks.blocked_id = user_id)
+    );
+$$;
+-- +goose StatementEnd
+
+-- chirp_visible_to now also hides chirps between users who block each other.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE SQL
+STABLE
+AS $$
+    SELECT (
+        visibility = 'public'
+        OR (viewer_id IS NOT NULL AND viewer_id = author_id)
+        OR (
+            visibility = 'followers'
+            AND viewer_id IS NOT NULL
+            AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+            )
+        )
+    )
+    AND (viewer_id IS NULL OR NOT users_blocked(author_id, viewer_id));
+$$;
+-- +goose StatementEnd
+
+-- chirp_in_feed_of reports whether a chirp belongs in a viewer's feeds and listings:
+-- it must be visible to the viewer and its author must not be muted by the viewer.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_in_feed_of(author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE SQL
+STABLE
+AS $$
+    SELECT chirp_visible_to(author_id, visibility, viewer_id)
+    AND NOT (
+        viewer_id IS NOT NULL
+        AND EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = viewer_id AND mutes.muted_id = author_id
+        )
+    );
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_in_feed_of(UUID, TEXT, UUID);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE SQL
+STABLE
+AS $$
+    SELECT visibility = 'public'
+        OR (viewer_id IS NOT NULL AND viewer_id = author_id)
+        OR (
+            visibility = 'followers'
+            AND viewer_id IS NOT NULL
+            AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+            )
+        );
+$$;
+-- +goose StatementEnd
+
+DROP FUNCTION users_blocked(UUID, UUID);
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,52 @@
+-- +goose Up
+CREATE TABLE chirp_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('created', 'deleted')),
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    visibility TEXT NOT NULL
+);
+
+CREATE INDEX chirp_events_created_at_idx ON chirp_events (created_at);
+
+-- record_chirp_event logs chirps being published or deleted and announces the event on the
+-- "chirp_events" channel, so that every server instance can push it to its stream subscribers.
+-- Notifications are only delivered once the transaction commits.
+-- +goose StatementBegin
+CREATE FUNCTION record_chirp_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.status <> 'published' THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'deleted', OLD.id, OLD.user_id, OLD.visibility)
+        RETURNING id INTO event_id;
+    ELSE
+        IF NEW.status <> 'published' OR (TG_OP = 'UPDATE' AND OLD.status = 'published') THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'created', NEW.id, NEW.user_id, NEW.visibility)
+        RETURNING id INTO event_id;
+    END IF;
+    PERFORM pg_notify('chirp_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_record_event
+AFTER INSERT OR UPDATE OF status OR DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION record_chirp_event();
+
+-- +goose Down
+DROP TRIGGER chirps_record_event ON chirps;
+DROP FUNCTION record_chirp_event();
+DROP TABLE chirp_events;
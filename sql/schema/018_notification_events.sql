@@ -0,0 +1,22 @@
+-- +goose Up
+-- announce_notification announces new notifications on the "notification_events" channel
+-- as "<user_id>:<notification_id>", so that realtime clients of any server instance get them.
+-- +goose StatementBegin
+CREATE FUNCTION announce_notification()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    PERFORM pg_notify('notification_events', NEW.user_id::text || ':' || NEW.id::text);
+    RETURN NULL;
+END;
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER notifications_announce
+AFTER INSERT ON notifications
+FOR EACH ROW EXECUTE FUNCTION announce_notification();
+
+-- +goose Down
+DROP TRIGGER notifications_announce ON notifications;
+DROP FUNCTION announce_notification();
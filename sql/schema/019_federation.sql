@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE actor_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    public_key_pem TEXT NOT NULL
+);
+
+CREATE TABLE remote_actors (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    uri TEXT NOT NULL UNIQUE,
+    inbox TEXT NOT NULL,
+    shared_inbox TEXT,
+    public_key_id TEXT NOT NULL,
+    public_key_pem TEXT NOT NULL
+);
+
+CREATE INDEX remote_actors_public_key_id_idx ON remote_actors (public_key_id);
+
+CREATE TABLE remote_follows (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remote_actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    follow_activity_id TEXT NOT NULL,
+    PRIMARY KEY (user_id, remote_actor_id)
+);
+
+CREATE TABLE federation_deliveries (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inbox TEXT NOT NULL,
+    activity TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT
+);
+
+CREATE INDEX federation_deliveries_next_attempt_at_idx ON federation_deliveries (next_attempt_at);
+
+-- +goose Down
+DROP TABLE federation_deliveries;
+DROP TABLE remote_follows;
+DROP TABLE remote_actors;
+DROP TABLE actor_keys;
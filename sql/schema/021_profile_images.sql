@@ -0,0 +1,11 @@
+-- +goose Up
+-- The keys name the image without being stored themselves: only its resized copies are, under the key with
+-- "_<size>" inserted before the extension. An empty key means no image.
+ALTER TABLE users
+    ADD COLUMN avatar_key TEXT NOT NULL DEFAULT '',
+    ADD COLUMN banner_key TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN banner_key,
+    DROP COLUMN avatar_key;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN language TEXT NOT NULL DEFAULT '';
+
+-- trends caches the ranked hashtags computed by the trends job.
+-- The empty language holds the trends across all languages.
+CREATE TABLE trends (
+    language TEXT NOT NULL,
+    rank INTEGER NOT NULL,
+    hashtag TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    uses BIGINT NOT NULL,
+    authors BIGINT NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (language, rank)
+);
+
+-- +goose Down
+DROP TABLE trends;
+
+ALTER TABLE chirps
+DROP COLUMN language;
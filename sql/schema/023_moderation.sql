@@ -0,0 +1,55 @@
+-- +goose Up
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'scheduled', 'hidden'));
+
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL
+        CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'misinformation', 'other')),
+    comment TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at, id);
+CREATE UNIQUE INDEX reports_open_chirp_idx ON reports (reporter_id, chirp_id)
+    WHERE status = 'open' AND chirp_id IS NOT NULL;
+CREATE UNIQUE INDEX reports_open_user_idx ON reports (reporter_id, user_id)
+    WHERE status = 'open' AND chirp_id IS NULL;
+
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('dismiss', 'hide_chirp', 'warn', 'suspend')),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    suspended_until TIMESTAMP
+);
+
+CREATE INDEX moderation_actions_created_at_idx ON moderation_actions (created_at DESC, id DESC);
+CREATE INDEX moderation_actions_user_id_idx ON moderation_actions (user_id);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_until;
+
+UPDATE chirps SET status = 'published' WHERE status = 'hidden';
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'scheduled'));
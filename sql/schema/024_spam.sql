@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'scheduled', 'hidden', 'held'));
+
+CREATE INDEX chirps_held_idx ON chirps (created_at, id) WHERE status = 'held';
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('dismiss', 'hide_chirp', 'warn', 'suspend', 'approve_chirp', 'reject_chirp'));
+
+-- +goose Down
+DELETE FROM moderation_actions WHERE action IN ('approve_chirp', 'reject_chirp');
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check CHECK (action IN ('dismiss', 'hide_chirp', 'warn', 'suspend'));
+
+DROP INDEX chirps_held_idx;
+
+UPDATE chirps SET status = 'hidden' WHERE status = 'held';
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_status_check,
+ADD CONSTRAINT chirps_status_check CHECK (status IN ('published', 'scheduled', 'hidden'));
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE posting_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX posting_events_user_id_created_at_idx ON posting_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE posting_events;
@@ -0,0 +1,61 @@
+-- +goose Up
+-- record_chirp_event also logs a "deleted" event when a published chirp changes status, e.g. when a
+-- moderator hides it, so that stream subscribers drop it like a deleted chirp.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_chirp_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND NEW.status <> 'published') THEN
+        IF OLD.status <> 'published' THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'deleted', OLD.id, OLD.user_id, OLD.visibility)
+        RETURNING id INTO event_id;
+    ELSE
+        IF NEW.status <> 'published' OR (TG_OP = 'UPDATE' AND OLD.status = 'published') THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'created', NEW.id, NEW.user_id, NEW.visibility)
+        RETURNING id INTO event_id;
+    END IF;
+    PERFORM pg_notify('chirp_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_chirp_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.status <> 'published' THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'deleted', OLD.id, OLD.user_id, OLD.visibility)
+        RETURNING id INTO event_id;
+    ELSE
+        IF NEW.status <> 'published' OR (TG_OP = 'UPDATE' AND OLD.status = 'published') THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO chirp_events (created_at, type, chirp_id, user_id, visibility)
+        VALUES (NOW(), 'created', NEW.id, NEW.user_id, NEW.visibility)
+        RETURNING id INTO event_id;
+    END IF;
+    PERFORM pg_notify('chirp_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE message_flags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX message_flags_message_id_idx ON message_flags (message_id);
+
+-- +goose Down
+DROP TABLE message_flags;
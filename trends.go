@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/alnah/go-httpserver/internal/database"
+	"github.com/alnah/go-httpserver/internal/trends"
+)
+
+const (
+	// trendsRefreshInterval is how often the trends cache is recomputed.
+	trendsRefreshInterval = 5 * time.Minute
+	// trendsCacheSize is the number of trends cached for each language.
+	trendsCacheSize = 50
+	// trendsMaxBlocks is the number of users blocking someone at which their chirps stop counting towards trends.
+	// Trends are computed once for everybody, so a viewer's own blocks can't apply to them; instead, a user
+	// blocked by several people is treated as blocked by the community, while a single block, which may be
+	// a personal dispute, doesn't remove anyone from the trends.
+	trendsMaxBlocks = 3
+)
+
+// trendsJob periodically ranks the hashtags of recent public chirps and stores the result in the trends table,
+// across all languages and for each language. Rechirps, and chirps from users with a recently flagged chirp or
+// blocked by trendsMaxBlocks users or more, don't count.
+type trendsJob struct {
+	db     *database.Queries
+	dbConn *sql.DB
+}
+
+// newTrendsJob creates a trends job backed by the given connection pool.
+func newTrendsJob(db *database.Queries, dbConn *sql.DB) *trendsJob {
+	return &trendsJob{db: db, dbConn: dbConn}
+}
+
+// Refresh recomputes the trends and replaces the cached ones in a single transaction,
+// so readers never see a partially written ranking. The transaction holds a lock, so that server
+// instances refreshing at the same time replace the ranking one after the other. It returns the
+// number of cached trends.
+func (j *trendsJob) Refresh(ctx context.Context) (int, error) {
+	// The windows are measured back from the database's clock, which dates chirps and flags.
+	usages, err := j.db.GetHashtagUsage(ctx, database.GetHashtagUsageParams{
+		WindowSeconds:   trends.Window.Seconds(),
+		BaselineSeconds: trends.Baseline.Seconds(),
+		MaxBlocks:       trendsMaxBlocks,
+	})
+	if err != nil {
+		return 0, err
+	}
+	byLanguage := make(map[string][]trends.Usage)
+	for _, u := range usages {
+		byLanguage[u.Language] = append(byLanguage[u.Language], trends.Usage{
+			Tag:      u.Name,
+			Current:  u.CurrentUses,
+			Previous: u.BaselineUses,
+			Authors:  u.CurrentAuthors,
+		})
+	}
+
+	tx, err := j.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	qtx := j.db.WithTx(tx)
+
+	err = qtx.LockTrendsRefresh(ctx)
+	if err != nil {
+		return 0, err
+	}
+	err = qtx.DeleteTrends(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	total := 0
+	for language, languageUsages := range byLanguage {
+		for i, trend := range trends.Rank(languageUsages, trendsCacheSize) {
+			err = qtx.CreateTrend(ctx, database.CreateTrendParams{
+				Language:   language,
+				Rank:       int32(i + 1),
+				Hashtag:    trend.Tag,
+				Score:      trend.Score,
+				Uses:       trend.Uses,
+				Authors:    trend.Authors,
+				ComputedAt: now,
+			})
+			if err != nil {
+				return 0, err
+			}
+			total++
+		}
+	}
+	return total, tx.Commit()
+}
+
+// Run refreshes the trends immediately and then periodically until ctx is done.
+func (j *trendsJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := j.Refresh(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Couldn't refresh trends", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
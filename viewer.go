@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alnah/go-httpserver/internal/auth"
+	"github.com/alnah/go-httpserver/internal/logging"
+	"github.com/google/uuid"
+)
+
+// validateJWT validates an access token and returns the ID of the user it was issued to, which it also
+// records on ctx for the access log.
+func (cfg *apiConfig) validateJWT(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	logging.SetUserID(ctx, userID.String())
+	return userID, nil
+}
+
+// viewerFromRequest identifies the caller of an endpoint that does not require authentication.
+// It returns an invalid (NULL) viewer for anonymous requests, and an error if the request
+// carries a bearer token that doesn't validate.
+func (cfg *apiConfig) viewerFromRequest(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	userID, err := cfg.validateJWT(r.Context(), token)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+// asViewer wraps the ID of an authenticated user as a viewer.
+func asViewer(userID uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}